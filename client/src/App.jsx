@@ -85,6 +85,7 @@ function App() {
   const getStatusColor = (status) => {
     switch (status) {
       case 'up': return '#22c55e'
+      case 'degraded': return '#f59e0b'
       case 'down': return '#ef4444'
       default: return '#94a3b8'
     }
@@ -93,6 +94,7 @@ function App() {
   const getStatusEmoji = (status) => {
     switch (status) {
       case 'up': return '🟢'
+      case 'degraded': return '🟡'
       case 'down': return '🔴'
       default: return '⚪'
     }
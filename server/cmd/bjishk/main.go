@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -20,6 +21,10 @@ import (
 func main() {
 	printHeader()
 
+	// Cancelled on interrupt so startup work and the ping handler stop promptly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, db, err := initialize()
 	if err != nil {
 		log.Fatalf("❌ Fatal error: %v\n", err)
@@ -34,54 +39,11 @@ func main() {
 
 	fmt.Println("\n📝 Loading patients...")
 
-	// Get all existing services from DB
-	allServices, err := db.GetAllServices()
-	if err != nil {
-		log.Fatalf("❌ Failed to get services: %v\n", err)
-	}
-
-	// Build map of services from config
-	configServices := make(map[string]bool)
-	for _, patientConfig := range patientsConfig.Patients {
-		configServices[patientConfig.URL] = true
-
-		existing, err := db.GetServiceByURL(patientConfig.URL)
-		if err != nil {
-			log.Printf("   ⚠️  Error checking patient: %v\n", err)
-			continue
-		}
-
-		if existing == nil {
-			checkInterval := cfg.Monitoring.DefaultCheckInterval
-			if patientConfig.CheckInterval != nil {
-				checkInterval = *patientConfig.CheckInterval
-			}
-			caregiver := patientConfig.Caregiver
-			if caregiver == "" {
-				caregiver = cfg.Caregiver
-			}
-			service, err := db.AddService(patientConfig.URL, checkInterval, &caregiver)
-			if err != nil {
-				log.Printf("   ⚠️  Failed to add patient: %v\n", err)
-				continue
-			}
-			fmt.Printf("   ➕ Added: %s\n", service.URL)
-		}
-	}
-
-	// Remove services not in config
-	for _, service := range allServices {
-		if !configServices[service.URL] {
-			if err := db.DeleteService(int(service.ID)); err != nil {
-				log.Printf("   ⚠️  Failed to delete patient: %v\n", err)
-			} else {
-				fmt.Printf("   ➖ Removed: %s\n", service.URL)
-			}
-		}
+	if _, err := syncPatients(ctx, cfg, db, patientsConfig.Patients); err != nil {
+		log.Fatalf("❌ %v\n", err)
 	}
 
-	// Refresh service list
-	allServices, err = db.GetAllServices()
+	allServices, err := db.GetAllServices(ctx)
 	if err != nil {
 		log.Fatalf("❌ Failed to get services: %v\n", err)
 	}
@@ -89,6 +51,10 @@ func main() {
 	if len(allServices) > 0 {
 		fmt.Println("   Patients:")
 		for _, svc := range allServices {
+			if svc.PingToken != nil {
+				fmt.Printf("     • %s → %s/api/ping/%s\n", svc.URL, cfg.BaseURL, *svc.PingToken)
+				continue
+			}
 			fmt.Printf("     • %s\n", svc.URL)
 		}
 	} else {
@@ -114,24 +80,37 @@ func main() {
 
 	// Service monitor
 	serviceMonitor := monitor.New(db, monitor.MonitorConfig{
-		Retries:    cfg.Monitoring.MaxRetries,
-		RetryDelay: 2,
-		Timeout:    10,
+		Retries:         cfg.Monitoring.MaxRetries,
+		RetryDelay:      2,
+		Timeout:         10,
+		CertWarningDays: cfg.Monitoring.CertWarningDays,
+		Network:         cfg.Network,
+		Workers:         cfg.Monitoring.Workers,
+		StartJitter:     cfg.Monitoring.StartJitter,
 	})
+	serviceMonitor.SetPatients(withPeerTLS(cfg.Peers, patientsConfig.Patients))
 	for i := range allServices {
 		serviceMonitor.StartMonitoring(&allServices[i])
 	}
 	fmt.Printf("   ✅ Patient monitoring (%d patient%s)\n", len(allServices), plural(len(allServices)))
 
 	// Federation service
+	peerTLS := make(map[string]config.TLSConfig)
+	for _, peer := range cfg.Peers {
+		peerTLS[strings.TrimSuffix(peer.URL, "/")] = peer.TLSConfig
+	}
 	fedService := federation.New(db, federation.FederationConfig{
 		Retries:           cfg.Monitoring.MaxRetries,
 		RetryDelay:        2,
 		PeerCheckInterval: 60,
+		Network:           cfg.Network,
+		PeerTLS:           peerTLS,
 	})
 
 	// HTTP server
 	httpServer := server.New(db, fedService, cfg.Name, cfg.Port, cfg.UI.RefreshInterval)
+	httpServer.SetSchedulerStats(serviceMonitor.SchedulerStats)
+	httpServer.SetPingHandler(serviceMonitor.CheckNow)
 	go func() {
 		if err := httpServer.Start(); err != nil {
 			log.Printf("❌ HTTP server error: %v\n", err)
@@ -144,12 +123,18 @@ func main() {
 
 	// Start background services
 	notifService.StartProcessing(cfg.Caregiver)
+	watchPatients(ctx, cfg, db, serviceMonitor)
 	go func() {
 		ticker := time.NewTicker(24 * time.Hour)
 		defer ticker.Stop()
-		for range ticker.C {
-			if deleted, err := db.CleanupOldLogs(cfg.MaxDaysLogs); err == nil && deleted > 0 {
-				log.Printf("🧹 Cleaned up %d old log entries\n", deleted)
+		for {
+			select {
+			case <-ticker.C:
+				if deleted, err := db.CleanupOldLogs(ctx, cfg.MaxDaysLogs); err == nil && deleted > 0 {
+					log.Printf("🧹 Cleaned up %d old log entries\n", deleted)
+				}
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
@@ -164,12 +149,11 @@ func main() {
 	fmt.Printf("  caregiver = \"%s\"\n", cfg.Caregiver)
 	fmt.Println("\n" + strings.Repeat("═", 60))
 
-	fmt.Println("\n✨ Bjishk is running! Press Ctrl+C to stop.\n")
+	fmt.Print("\n✨ Bjishk is running! Press Ctrl+C to stop.\n\n")
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	// Wait for interrupt signal; a second one exits immediately
+	<-ctx.Done()
+	stop()
 
 	// Graceful shutdown
 	fmt.Println("\n\n🛑 Shutting down gracefully...")
@@ -182,14 +166,14 @@ func main() {
 	db.Close()
 
 	fmt.Println("💾 Database closed")
-	fmt.Println("👋 Goodbye!\n")
+	fmt.Print("👋 Goodbye!\n\n")
 }
 
 func printHeader() {
 	fmt.Println("╔═══════════════════════════════════════╗")
 	fmt.Println("║           🏥 BJISHK v1.0             ║")
 	fmt.Println("║   Decentralized Health Monitoring    ║")
-	fmt.Println("╚═══════════════════════════════════════╝\n")
+	fmt.Print("╚═══════════════════════════════════════╝\n\n")
 }
 
 func plural(n int) string {
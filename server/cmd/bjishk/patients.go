@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/database"
+	"github.com/yourusername/bjishk/internal/monitor"
+	"github.com/yourusername/bjishk/pkg/models"
+)
+
+// patientChanges lists the services a sync added, updated or removed.
+type patientChanges struct {
+	added   []models.Service
+	updated []models.Service
+	removed []models.Service
+}
+
+func (c *patientChanges) empty() bool {
+	return len(c.added) == 0 && len(c.updated) == 0 && len(c.removed) == 0
+}
+
+// syncPatients makes the services in the database match patients.toml,
+// which wins over settings stored for existing patients.
+func syncPatients(ctx context.Context, cfg *config.Config, db *database.DB, patients []config.PatientEntry) (*patientChanges, error) {
+	// Get all existing services from DB
+	allServices, err := db.GetAllServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	changes := &patientChanges{}
+
+	// Build map of services from config
+	configServices := make(map[string]bool)
+	for _, patientConfig := range patients {
+		configServices[patientConfig.URL] = true
+	}
+
+	// Remove services not in config first, freeing their ping tokens for
+	// renamed patients
+	for _, service := range allServices {
+		if !configServices[service.URL] {
+			if err := db.DeleteService(ctx, int(service.ID)); err != nil {
+				log.Printf("   ⚠️  Failed to delete patient: %v\n", err)
+			} else {
+				fmt.Printf("   ➖ Removed: %s\n", service.URL)
+				changes.removed = append(changes.removed, service)
+			}
+		}
+	}
+
+	for _, patientConfig := range patients {
+		if _, err := monitor.ProberFor(patientConfig.URL, patientConfig.Type); err != nil {
+			log.Printf("   ⚠️  %s: %v\n", patientConfig.URL, err)
+		}
+
+		checkInterval := cfg.Monitoring.DefaultCheckInterval
+		if patientConfig.CheckInterval != nil {
+			checkInterval = *patientConfig.CheckInterval
+		}
+		caregiver := patientConfig.Caregiver
+		if caregiver == "" {
+			caregiver = cfg.Caregiver
+		}
+
+		upsert, err := db.UpsertService(ctx, patientConfig.URL, checkInterval, &caregiver)
+		if err != nil {
+			log.Printf("   ⚠️  Failed to sync patient %s: %v\n", patientConfig.URL, err)
+			continue
+		}
+		existing := upsert.Service
+		if upsert.Created {
+			fmt.Printf("   ➕ Added: %s\n", existing.URL)
+		} else if upsert.Restored {
+			fmt.Printf("   ♻️  Restored: %s\n", existing.URL)
+		}
+		if len(upsert.Changes) > 0 {
+			fmt.Printf("   ✏️  Updated: %s (%s)\n", existing.URL, strings.Join(upsert.Changes, ", "))
+		}
+
+		if patientConfig.Kind() == "heartbeat" {
+			if err := ensurePingToken(ctx, db, existing, patientConfig.Token); err != nil {
+				log.Printf("   ⚠️  Failed to set ping token: %v\n", err)
+			}
+		}
+
+		if upsert.Created || upsert.Restored {
+			changes.added = append(changes.added, *existing)
+		} else if len(upsert.Changes) > 0 {
+			changes.updated = append(changes.updated, *existing)
+		}
+	}
+
+	return changes, nil
+}
+
+// withPeerTLS returns the patients with the TLS settings of the [[peers]]
+// entry their URL falls under, for patients that have none of their own.
+// Other instances are monitored as patients through their /api/health URL.
+func withPeerTLS(peers []config.PeerEntry, patients []config.PatientEntry) []config.PatientEntry {
+	result := make([]config.PatientEntry, len(patients))
+	copy(result, patients)
+
+	for i := range result {
+		if result[i].TLSConfig != (config.TLSConfig{}) {
+			continue
+		}
+		for _, peer := range peers {
+			peerURL := strings.TrimSuffix(peer.URL, "/")
+			if result[i].URL == peerURL || strings.HasPrefix(result[i].URL, peerURL+"/") {
+				result[i].TLSConfig = peer.TLSConfig
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ensurePingToken gives a heartbeat patient its configured ping token, or a
+// generated one if it has none yet.
+func ensurePingToken(ctx context.Context, db *database.DB, service *models.Service, token string) error {
+	if token == "" {
+		if service.PingToken != nil {
+			return nil
+		}
+		generated, err := monitor.NewPingToken()
+		if err != nil {
+			return err
+		}
+		token = generated
+	}
+
+	if service.PingToken != nil && *service.PingToken == token {
+		return nil
+	}
+	if err := db.UpdateService(ctx, int(service.ID), map[string]interface{}{"ping_token": token}); err != nil {
+		return err
+	}
+	service.PingToken = &token
+	return nil
+}
+
+// watchPatients reloads patients.toml when it changes on disk or the
+// process receives SIGHUP, until ctx is done.
+func watchPatients(ctx context.Context, cfg *config.Config, db *database.DB, serviceMonitor *monitor.Monitor) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	changes, err := config.WatchPatients(ctx)
+	if err != nil {
+		log.Printf("⚠️  Not watching patients.toml (reload with SIGHUP): %v\n", err)
+	}
+
+	go func() {
+		defer signal.Stop(hangup)
+		for {
+			select {
+			case <-hangup:
+			case <-changes:
+			case <-ctx.Done():
+				return
+			}
+			reloadPatients(ctx, cfg, db, serviceMonitor)
+		}
+	}()
+}
+
+// reloadPatients applies patients.toml to the database and starts, stops or
+// reschedules only the monitors it affects. An invalid file is reported and
+// the current patients are kept.
+func reloadPatients(ctx context.Context, cfg *config.Config, db *database.DB, serviceMonitor *monitor.Monitor) {
+	fmt.Println("\n🔄 Reloading patients...")
+
+	patientsConfig, err := config.LoadPatients()
+	if err != nil {
+		log.Printf("   ⚠️  Keeping current patients: %v\n", err)
+		return
+	}
+
+	changes, err := syncPatients(ctx, cfg, db, patientsConfig.Patients)
+	if err != nil {
+		log.Printf("   ⚠️  %v\n", err)
+		return
+	}
+
+	serviceMonitor.SetPatients(withPeerTLS(cfg.Peers, patientsConfig.Patients))
+	for _, service := range changes.removed {
+		serviceMonitor.StopMonitoring(service.ID)
+	}
+	for i := range changes.updated {
+		serviceMonitor.Reschedule(&changes.updated[i])
+	}
+	for i := range changes.added {
+		serviceMonitor.StartMonitoring(&changes.added[i])
+	}
+
+	if changes.empty() {
+		fmt.Println("   No patient changes")
+	}
+}
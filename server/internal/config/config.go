@@ -1,22 +1,28 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 type Config struct {
-	Name        string `toml:"name"`
-	Caregiver   string `toml:"caregiver"`
-	Port        int    `toml:"port"`
-	BaseURL     string `toml:"base_url"`
-	MaxDaysLogs int    `toml:"max_days_logs"`
+	Name        string           `toml:"name"`
+	Caregiver   string           `toml:"caregiver"`
+	Port        int              `toml:"port"`
+	BaseURL     string           `toml:"base_url"`
+	MaxDaysLogs int              `toml:"max_days_logs"`
 	Database    DatabaseConfig   `toml:"database"`
 	Email       EmailConfig      `toml:"email"`
 	Monitoring  MonitoringConfig `toml:"monitoring"`
+	Network     NetworkConfig    `toml:"network"`
+	Peers       []PeerEntry      `toml:"peers"`
 	UI          UIConfig         `toml:"ui"`
 }
 
@@ -33,10 +39,99 @@ type EmailConfig struct {
 }
 
 type MonitoringConfig struct {
-	DefaultCheckInterval int `toml:"default_check_interval"`
-	Timeout              int `toml:"timeout"`
-	MaxRetries           int `toml:"max_retries"`
-	FailureThreshold     int `toml:"failure_threshold"`
+	DefaultCheckInterval int   `toml:"default_check_interval"`
+	Timeout              int   `toml:"timeout"`
+	MaxRetries           int   `toml:"max_retries"`
+	FailureThreshold     int   `toml:"failure_threshold"`
+	Workers              int   `toml:"workers"`           // Concurrent checks, defaults to 10
+	StartJitter          int   `toml:"start_jitter"`      // Seconds to spread initial checks over, defaults to 30
+	CertWarningDays      []int `toml:"cert_warning_days"` // Days before expiry to send "expiring" notifications
+}
+
+// NetworkConfig controls how outbound check connections are made. It is set
+// globally under [network] and can be overridden per patient.
+type NetworkConfig struct {
+	Proxy    string            `toml:"proxy"`    // Optional: http://, https:// or socks5:// URL, "direct" ignores the global and environment proxy
+	Resolver string            `toml:"resolver"` // Optional: DNS server as host[:port]
+	Resolve  map[string]string `toml:"resolve"`  // Optional: "host" or "host:port" = "address" overrides, like curl --resolve
+}
+
+// Merge returns n with the settings of override applied on top. Resolve
+// overrides are combined.
+func (n NetworkConfig) Merge(override NetworkConfig) NetworkConfig {
+	merged := n
+	if override.Proxy != "" {
+		merged.Proxy = override.Proxy
+	}
+	if override.Resolver != "" {
+		merged.Resolver = override.Resolver
+	}
+	if len(override.Resolve) > 0 {
+		merged.Resolve = make(map[string]string, len(n.Resolve)+len(override.Resolve))
+		for host, address := range n.Resolve {
+			merged.Resolve[host] = address
+		}
+		for host, address := range override.Resolve {
+			merged.Resolve[host] = address
+		}
+	}
+	return merged
+}
+
+func (n *NetworkConfig) Validate() error {
+	if n.Proxy != "" && n.Proxy != "direct" {
+		u, err := url.Parse(n.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported proxy scheme: %q", u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("proxy %q missing host", n.Proxy)
+		}
+	}
+	if n.Resolver != "" && strings.Contains(n.Resolver, "/") {
+		return fmt.Errorf("resolver must be host[:port], got %q", n.Resolver)
+	}
+	for host, address := range n.Resolve {
+		if host == "" || address == "" {
+			return fmt.Errorf("invalid resolve override %q = %q", host, address)
+		}
+	}
+	return nil
+}
+
+// TLSConfig holds the client side TLS settings of a patient or peer.
+type TLSConfig struct {
+	ClientCert string `toml:"tls_cert"`        // Optional: PEM client certificate for mTLS
+	ClientKey  string `toml:"tls_key"`         // Optional: PEM private key for tls_cert
+	CABundle   string `toml:"tls_ca"`          // Optional: PEM CA bundle trusted instead of the system roots
+	ServerName string `toml:"tls_server_name"` // Optional: name to send as SNI and verify the certificate against
+	Insecure   bool   `toml:"tls_insecure"`    // Optional: skip certificate verification
+}
+
+func (t *TLSConfig) Validate() error {
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return fmt.Errorf("tls_cert and tls_key must be set together")
+	}
+	for _, path := range []string{t.ClientCert, t.ClientKey, t.CABundle} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("TLS file not found: %s", path)
+		}
+	}
+	return nil
+}
+
+// PeerEntry holds per-peer connection settings for federation checks.
+type PeerEntry struct {
+	URL string `toml:"url"`
+	TLSConfig
 }
 
 type UIConfig struct {
@@ -49,8 +144,291 @@ type PatientsConfig struct {
 
 type PatientEntry struct {
 	URL           string `toml:"url"`
+	Type          string `toml:"type"` // Optional: probe type, defaults to the URL scheme
 	CheckInterval *int   `toml:"check_interval"`
 	Caregiver     string `toml:"caregiver"` // Optional: notify this email, defaults to server caregiver
+	Timeout       *int   `toml:"timeout"`   // Optional: seconds, overrides the monitoring timeout
+
+	WarningLatency  int `toml:"warning_latency"`  // Optional: ms, slower responses are "degraded"
+	CriticalLatency int `toml:"critical_latency"` // Optional: ms, slower responses are "down"
+
+	CertWarningDays []int `toml:"cert_warning_days"` // Optional: overrides monitoring.cert_warning_days
+
+	// HTTP request shape and response assertions (http(s)://)
+	HTTPRequest
+	Assertions
+
+	// Proxy (HTTP and WebSocket checks) and name resolution, overrides [network]
+	NetworkConfig
+
+	// Dual-stack checks: "family" checks one IPv4 and one IPv6 address
+	// separately, "all" checks every resolved address
+	CheckAddresses string `toml:"check_addresses"`
+
+	// TLS client settings (https://, tls://, grpcs://, wss://, mail STARTTLS)
+	TLSConfig
+
+	// Content change detection (http(s)://)
+	DetectChanges  bool   `toml:"detect_changes"`
+	ChangeSelector string `toml:"change_selector"` // Optional: "css:<selector>" or "regex:<pattern>", defaults to the whole body
+
+	// Raw socket and WebSocket checks (tcp://, udp://, ws(s)://)
+	Send    string `toml:"send"`     // Optional: payload written after connecting
+	SendHex string `toml:"send_hex"` // Optional: binary payload as hex, takes precedence over send
+	Expect  string `toml:"expect"`   // Optional: regex the reply must match
+
+	// Packet checks (udp://, icmp://)
+	Count int `toml:"count"` // Optional: packets to send, defaults to 3
+
+	// Mail server checks (smtp://, imap://, pop3://)
+	StartTLS bool `toml:"starttls"` // Optional: upgrade the connection and check its certificate
+
+	// DNS checks (dns://[resolver]/name?type=A)
+	ExpectRecords []string `toml:"expect_records"` // Optional: values that must be in the answer
+
+	// Database checks (postgres://, mysql://, redis://)
+	Query             string `toml:"query"`               // Optional: defaults to SELECT 1, or PING for Redis
+	ExpectResult      string `toml:"expect_result"`       // Optional: first column of the first row, or the Redis reply
+	MaxReplicationLag *int   `toml:"max_replication_lag"` // Optional: seconds a replica may lag behind its primary
+	Username          string `toml:"username"`            // Optional: kept out of url, which is shown in logs, emails and the API
+	Password          string `toml:"password"`            // Optional
+	PasswordFile      string `toml:"password_file"`       // Optional: read on every check instead of password
+
+	// Prometheus scrape checks (type = "prometheus" with an http(s):// URL)
+	MetricAssertions []string `toml:"metric_assertions"` // e.g. "up == 1"; the patient is down when one fails
+	MetricWarnings   []string `toml:"metric_warnings"`   // Optional: e.g. "queue_depth < 1000"; the patient is degraded when one fails
+
+	// Heartbeat patients (heartbeat://name), pinged at /api/ping/<token>
+	Period int    `toml:"period"` // Seconds between expected pings
+	Grace  int    `toml:"grace"`  // Optional: seconds a ping may be late, and a started job may run (defaults to period)
+	Token  string `toml:"token"`  // Optional: secret ping token, generated if empty
+
+	// Script checks (exec://name), Nagios plugin compatible
+	Command []string `toml:"command"` // Program and arguments
+
+	// Multi-step HTTP transactions (steps://name)
+	Steps []Step `toml:"steps"`
+}
+
+// Kind returns the patient's probe type: the explicit type, or the URL
+// scheme.
+func (p *PatientEntry) Kind() string {
+	if p.Type != "" {
+		return strings.ToLower(p.Type)
+	}
+	if u, err := url.Parse(p.URL); err == nil {
+		return strings.ToLower(u.Scheme)
+	}
+	return ""
+}
+
+// Step is one request of a multi-step transaction. Values captured from a
+// step can be used in later steps as {{name}} in the URL, headers, body and
+// credentials. Cookies are kept across steps.
+type Step struct {
+	Name    string            `toml:"name"` // Optional: defaults to step<N>
+	URL     string            `toml:"url"`
+	Capture map[string]string `toml:"capture"` // e.g. token = "json:$.token", csrf = "header:X-CSRF", sid = "cookie:SID", id = "regex:id=(\\d+)"
+	HTTPRequest
+	Assertions
+}
+
+func (s *Step) Validate() error {
+	if s.URL == "" {
+		return fmt.Errorf("step missing required field: url")
+	}
+	for name, source := range s.Capture {
+		kind, expr, _ := strings.Cut(source, ":")
+		switch kind {
+		case "json", "header", "cookie":
+		case "regex":
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("capture %s: invalid regex: %w", name, err)
+			}
+		default:
+			return fmt.Errorf("capture %s: unknown source %q (use json:, header:, cookie: or regex:)", name, source)
+		}
+	}
+	if err := s.HTTPRequest.Validate(); err != nil {
+		return err
+	}
+	return s.Assertions.Validate()
+}
+
+// HTTPRequest describes how an HTTP check request is built and which
+// responses count as healthy.
+type HTTPRequest struct {
+	Method          string            `toml:"method"` // Optional: defaults to GET
+	Headers         map[string]string `toml:"headers"`
+	Body            string            `toml:"body"`
+	BasicAuth       *BasicAuth        `toml:"basic_auth"`
+	BearerToken     string            `toml:"bearer_token"`
+	AcceptStatus    []string          `toml:"accept_status"`    // Optional: e.g. ["200-299", "301", "401"], defaults to 2xx
+	FollowRedirects *bool             `toml:"follow_redirects"` // Optional: defaults to true
+	MaxRedirects    int               `toml:"max_redirects"`    // Optional: defaults to 10
+}
+
+type BasicAuth struct {
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+}
+
+func (r *HTTPRequest) Validate() error {
+	for _, accept := range r.AcceptStatus {
+		if _, _, err := parseStatusRange(accept); err != nil {
+			return err
+		}
+	}
+	if r.BasicAuth != nil && r.BearerToken != "" {
+		return fmt.Errorf("basic_auth and bearer_token are mutually exclusive")
+	}
+	if r.MaxRedirects < 0 {
+		return fmt.Errorf("max_redirects must not be negative")
+	}
+	return nil
+}
+
+// AcceptsStatus reports whether an HTTP status code counts as healthy.
+func (r *HTTPRequest) AcceptsStatus(code int) bool {
+	if len(r.AcceptStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, accept := range r.AcceptStatus {
+		low, high, err := parseStatusRange(accept)
+		if err == nil && code >= low && code <= high {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatusRange parses "200", "200-299" or "2xx".
+func parseStatusRange(s string) (int, int, error) {
+	s = strings.TrimSpace(s)
+
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") {
+		class, err := strconv.Atoi(s[:1])
+		if err != nil || class < 1 || class > 5 {
+			return 0, 0, fmt.Errorf("invalid status range: %q", s)
+		}
+		return class * 100, class*100 + 99, nil
+	}
+
+	lowStr, highStr, isRange := strings.Cut(s, "-")
+	low, err := strconv.Atoi(strings.TrimSpace(lowStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status range: %q", s)
+	}
+	high := low
+	if isRange {
+		high, err = strconv.Atoi(strings.TrimSpace(highStr))
+		if err != nil || high < low {
+			return 0, 0, fmt.Errorf("invalid status range: %q", s)
+		}
+	}
+	return low, high, nil
+}
+
+// Assertions are evaluated against a response body; any failure marks the
+// patient down.
+type Assertions struct {
+	MustContain    []string        `toml:"must_contain"`
+	MustNotContain []string        `toml:"must_not_contain"`
+	BodyRegex      []string        `toml:"body_regex"`
+	JSONPath       []JSONAssertion `toml:"json_path"`
+	MaxBodySize    int64           `toml:"max_body_size"` // Bytes, 0 means unlimited
+}
+
+type JSONAssertion struct {
+	Path  string      `toml:"path"`  // e.g. "$.status" or "$.checks[0].ok"
+	Value interface{} `toml:"value"` // Optional: expected value, otherwise the path must exist
+}
+
+func (a *Assertions) Validate() error {
+	for _, pattern := range a.BodyRegex {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid body_regex %q: %w", pattern, err)
+		}
+	}
+	for _, assertion := range a.JSONPath {
+		if assertion.Path == "" {
+			return fmt.Errorf("json_path assertion missing path")
+		}
+	}
+	if a.MaxBodySize < 0 {
+		return fmt.Errorf("max_body_size must not be negative")
+	}
+	return nil
+}
+
+// MetricExpression compares every sample of a Prometheus series with a
+// constant, e.g. `http_errors_total{code="500"} < 10`.
+type MetricExpression struct {
+	Name     string
+	Labels   map[string]string
+	Operator string
+	Value    float64
+}
+
+var (
+	metricExpressionRegex = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(?:\{(.*)\})?\s*(==|!=|<=|>=|<|>)\s*(\S+)$`)
+	labelMatcherRegex     = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*"((?:[^"\\]|\\.)*)"\s*(?:,|$)`)
+)
+
+// ParseMetricExpression parses `name{label="value",...} <op> number` where op
+// is one of ==, !=, <, <=, >, >=.
+func ParseMetricExpression(s string) (*MetricExpression, error) {
+	matches := metricExpressionRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return nil, fmt.Errorf("invalid metric expression: %q", s)
+	}
+
+	value, err := strconv.ParseFloat(matches[4], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric expression %q: bad value %q", s, matches[4])
+	}
+
+	expr := &MetricExpression{
+		Name:     matches[1],
+		Labels:   make(map[string]string),
+		Operator: matches[3],
+		Value:    value,
+	}
+
+	labels := matches[2]
+	for strings.TrimSpace(labels) != "" {
+		label := labelMatcherRegex.FindStringSubmatch(labels)
+		if label == nil {
+			return nil, fmt.Errorf("invalid metric expression %q: bad label matcher", s)
+		}
+		value, err := strconv.Unquote(`"` + label[2] + `"`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric expression %q: %w", s, err)
+		}
+		expr.Labels[label[1]] = value
+		labels = labels[len(label[0]):]
+	}
+
+	return expr, nil
+}
+
+// Holds reports whether a sample value satisfies the expression.
+func (e *MetricExpression) Holds(value float64) bool {
+	switch e.Operator {
+	case "==":
+		return value == e.Value
+	case "!=":
+		return value != e.Value
+	case "<":
+		return value < e.Value
+	case "<=":
+		return value <= e.Value
+	case ">":
+		return value > e.Value
+	case ">=":
+		return value >= e.Value
+	}
+	return false
 }
 
 func LoadConfig() (*Config, error) {
@@ -80,6 +458,18 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("missing required field: base_url")
 	}
 
+	if err := config.Network.Validate(); err != nil {
+		return nil, fmt.Errorf("network: %w", err)
+	}
+	for i, peer := range config.Peers {
+		if peer.URL == "" {
+			return nil, fmt.Errorf("peer %d missing required field: url", i)
+		}
+		if err := peer.TLSConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("peer %s: %w", peer.URL, err)
+		}
+	}
+
 	// Set defaults
 	if config.MaxDaysLogs == 0 {
 		config.MaxDaysLogs = 30
@@ -88,9 +478,9 @@ func LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
-func LoadPatients() (*PatientsConfig, error) {
-	patientsPath := "patients.toml"
+const patientsPath = "patients.toml"
 
+func LoadPatients() (*PatientsConfig, error) {
 	data, err := os.ReadFile(patientsPath)
 	if err != nil {
 		return nil, fmt.Errorf("patients file not found: %s", patientsPath)
@@ -110,6 +500,86 @@ func LoadPatients() (*PatientsConfig, error) {
 		if _, err := url.Parse(patient.URL); err != nil {
 			return nil, fmt.Errorf("invalid URL format: %s", patient.URL)
 		}
+		if patient.Expect != "" {
+			if _, err := regexp.Compile(patient.Expect); err != nil {
+				return nil, fmt.Errorf("patient %s has invalid expect pattern: %w", patient.URL, err)
+			}
+		}
+		kind := patient.Kind()
+		if kind == "heartbeat" && patient.Period <= 0 {
+			return nil, fmt.Errorf("patient %s missing required field: period", patient.URL)
+		}
+		if kind == "exec" && len(patient.Command) == 0 {
+			return nil, fmt.Errorf("patient %s missing required field: command", patient.URL)
+		}
+		if patient.SendHex != "" {
+			if _, err := hex.DecodeString(strings.ReplaceAll(patient.SendHex, " ", "")); err != nil {
+				return nil, fmt.Errorf("patient %s has invalid send_hex: %w", patient.URL, err)
+			}
+		}
+		if patient.Count < 0 {
+			return nil, fmt.Errorf("patient %s: count must not be negative", patient.URL)
+		}
+		if patient.ChangeSelector != "" {
+			kind, expr, _ := strings.Cut(patient.ChangeSelector, ":")
+			if kind != "css" && kind != "regex" {
+				return nil, fmt.Errorf("patient %s: change_selector must start with css: or regex:", patient.URL)
+			}
+			if kind == "regex" {
+				if _, err := regexp.Compile(expr); err != nil {
+					return nil, fmt.Errorf("patient %s: invalid change_selector: %w", patient.URL, err)
+				}
+			}
+		}
+		if kind == "steps" && len(patient.Steps) == 0 {
+			return nil, fmt.Errorf("patient %s missing required field: steps", patient.URL)
+		}
+		for j := range patient.Steps {
+			if err := patient.Steps[j].Validate(); err != nil {
+				return nil, fmt.Errorf("patient %s step %d: %w", patient.URL, j+1, err)
+			}
+		}
+		if kind == "prometheus" && len(patient.MetricAssertions) == 0 && len(patient.MetricWarnings) == 0 {
+			return nil, fmt.Errorf("patient %s missing required field: metric_assertions", patient.URL)
+		}
+		for _, expr := range append(append([]string{}, patient.MetricAssertions...), patient.MetricWarnings...) {
+			if _, err := ParseMetricExpression(expr); err != nil {
+				return nil, fmt.Errorf("patient %s: %w", patient.URL, err)
+			}
+		}
+		if patient.CheckAddresses != "" && patient.CheckAddresses != "family" && patient.CheckAddresses != "all" {
+			return nil, fmt.Errorf("patient %s: check_addresses must be \"family\" or \"all\"", patient.URL)
+		}
+		if patient.CheckAddresses != "" && patient.Proxy != "" && patient.Proxy != "direct" {
+			return nil, fmt.Errorf("patient %s: check_addresses cannot be used with a proxy", patient.URL)
+		}
+		if patient.Password != "" && patient.PasswordFile != "" {
+			return nil, fmt.Errorf("patient %s: password and password_file are mutually exclusive", patient.URL)
+		}
+		if patient.MaxReplicationLag != nil && *patient.MaxReplicationLag < 0 {
+			return nil, fmt.Errorf("patient %s: max_replication_lag must not be negative", patient.URL)
+		}
+		if patient.Timeout != nil && *patient.Timeout <= 0 {
+			return nil, fmt.Errorf("patient %s: timeout must be positive", patient.URL)
+		}
+		if patient.WarningLatency < 0 || patient.CriticalLatency < 0 {
+			return nil, fmt.Errorf("patient %s: latency thresholds must not be negative", patient.URL)
+		}
+		if patient.WarningLatency > 0 && patient.CriticalLatency > 0 && patient.WarningLatency >= patient.CriticalLatency {
+			return nil, fmt.Errorf("patient %s: warning_latency must be below critical_latency", patient.URL)
+		}
+		if err := patient.HTTPRequest.Validate(); err != nil {
+			return nil, fmt.Errorf("patient %s: %w", patient.URL, err)
+		}
+		if err := patient.Assertions.Validate(); err != nil {
+			return nil, fmt.Errorf("patient %s: %w", patient.URL, err)
+		}
+		if err := patient.NetworkConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("patient %s: %w", patient.URL, err)
+		}
+		if err := patient.TLSConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("patient %s: %w", patient.URL, err)
+		}
 	}
 
 	return &patients, nil
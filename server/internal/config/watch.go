@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// patientsSettle is how long patients.toml must be quiet before a change is
+// reported, so a save that produces several events triggers one reload.
+const patientsSettle = 500 * time.Millisecond
+
+// WatchPatients reports changes to patients.toml until ctx is done. The
+// directory is watched rather than the file so that editors which save by
+// replacing the file are still followed.
+func WatchPatients(ctx context.Context) (<-chan struct{}, error) {
+	path, err := filepath.Abs(patientsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+
+		var settle <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Name == path && event.Has(fsnotify.Write|fsnotify.Create) {
+					settle = time.After(patientsSettle)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("⚠️  Watching %s: %v\n", patientsPath, err)
+			case <-settle:
+				settle = nil
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return changes, nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -35,16 +36,24 @@ func New(dbPath string) (*DB, error) {
 
 func (db *DB) Initialize() error {
 	// Auto-migrate all models
-	return db.conn.AutoMigrate(
+	if err := db.conn.AutoMigrate(
 		&models.Service{},
 		&models.Peer{},
 		&models.Notification{},
 		&models.Log{},
-	)
+	); err != nil {
+		return err
+	}
+
+	// Release ping tokens still held by services deleted before
+	// DeleteService cleared them
+	return db.conn.Unscoped().Model(&models.Service{}).
+		Where("deleted_at IS NOT NULL AND ping_token IS NOT NULL").
+		Update("ping_token", nil).Error
 }
 
 // Service operations
-func (db *DB) AddService(url string, checkInterval int, caregiver *string) (*models.Service, error) {
+func (db *DB) AddService(ctx context.Context, url string, checkInterval int, caregiver *string) (*models.Service, error) {
 	service := &models.Service{
 		URL:           url,
 		Caregiver:     caregiver,
@@ -52,16 +61,113 @@ func (db *DB) AddService(url string, checkInterval int, caregiver *string) (*mod
 		Status:        "unknown",
 	}
 
-	if err := db.conn.Create(service).Error; err != nil {
+	if err := db.conn.WithContext(ctx).Create(service).Error; err != nil {
 		return nil, err
 	}
 
 	return service, nil
 }
 
-func (db *DB) GetService(id int) (*models.Service, error) {
+// ServiceUpsert describes what UpsertService did.
+type ServiceUpsert struct {
+	Service  *models.Service
+	Created  bool
+	Restored bool     // A soft-deleted row for the URL was brought back
+	Changes  []string // Settings that changed, as "field: old → new"
+}
+
+// UpsertService makes the service for url match the given settings, creating
+// it if needed. A previously deleted service with the same URL is restored
+// rather than colliding with its unique index.
+func (db *DB) UpsertService(ctx context.Context, url string, checkInterval int, caregiver *string) (*ServiceUpsert, error) {
+	result := &ServiceUpsert{}
+
+	err := db.conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var service models.Service
+		err := tx.Unscoped().Where("url = ?", url).First(&service).Error
+		if err == gorm.ErrRecordNotFound {
+			service = models.Service{
+				URL:           url,
+				Caregiver:     caregiver,
+				CheckInterval: checkInterval,
+				Status:        "unknown",
+			}
+			result.Service = &service
+			result.Created = true
+			return tx.Create(&service).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := make(map[string]interface{})
+		if service.DeletedAt.Valid {
+			// State from before the removal is stale. Heartbeats count from
+			// created_at until the first ping, so it restarts too.
+			updates["deleted_at"] = nil
+			updates["created_at"] = time.Now()
+			updates["status"] = "unknown"
+			updates["consecutive_failures"] = 0
+			updates["last_ping"] = nil
+			updates["last_ping_status"] = nil
+			updates["cert_notified_days"] = nil
+			updates["content_hash"] = nil
+			updates["content_snapshot"] = nil
+			result.Restored = true
+		}
+		if service.CheckInterval != checkInterval {
+			updates["check_interval"] = checkInterval
+			result.Changes = append(result.Changes, fmt.Sprintf("check_interval: %d → %d", service.CheckInterval, checkInterval))
+		}
+		if from, to := stringValue(service.Caregiver), stringValue(caregiver); from != to {
+			updates["caregiver"] = caregiver
+			result.Changes = append(result.Changes, fmt.Sprintf("caregiver: %q → %q", from, to))
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Unscoped().Model(&models.Service{}).Where("id = ?", service.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			// Into a fresh value, scanning NULL leaves old fields in place
+			var updated models.Service
+			if err := tx.First(&updated, service.ID).Error; err != nil {
+				return err
+			}
+			service = updated
+		}
+
+		result.Service = &service
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (db *DB) GetService(ctx context.Context, id int) (*models.Service, error) {
+	var service models.Service
+	err := db.conn.WithContext(ctx).First(&service, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+func (db *DB) GetServiceByURL(ctx context.Context, url string) (*models.Service, error) {
 	var service models.Service
-	err := db.conn.First(&service, id).Error
+	err := db.conn.WithContext(ctx).Where("url = ?", url).First(&service).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -71,9 +177,9 @@ func (db *DB) GetService(id int) (*models.Service, error) {
 	return &service, nil
 }
 
-func (db *DB) GetServiceByURL(url string) (*models.Service, error) {
+func (db *DB) GetServiceByPingToken(ctx context.Context, token string) (*models.Service, error) {
 	var service models.Service
-	err := db.conn.Where("url = ?", url).First(&service).Error
+	err := db.conn.WithContext(ctx).Where("ping_token = ?", token).First(&service).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -83,38 +189,45 @@ func (db *DB) GetServiceByURL(url string) (*models.Service, error) {
 	return &service, nil
 }
 
-func (db *DB) GetAllServices() ([]models.Service, error) {
+func (db *DB) GetAllServices(ctx context.Context) ([]models.Service, error) {
 	var services []models.Service
-	err := db.conn.Find(&services).Error
+	err := db.conn.WithContext(ctx).Find(&services).Error
 	return services, err
 }
 
-func (db *DB) UpdateService(id int, data map[string]interface{}) error {
-	return db.conn.Model(&models.Service{}).Where("id = ?", id).Updates(data).Error
+func (db *DB) UpdateService(ctx context.Context, id int, data map[string]interface{}) error {
+	return db.conn.WithContext(ctx).Model(&models.Service{}).Where("id = ?", id).Updates(data).Error
 }
 
-func (db *DB) DeleteService(id int) error {
-	return db.conn.Delete(&models.Service{}, id).Error
+// DeleteService soft-deletes a service. Its ping token is released, as the
+// unique index still covers deleted rows.
+func (db *DB) DeleteService(ctx context.Context, id int) error {
+	return db.conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Service{}).Where("id = ?", id).Update("ping_token", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Service{}, id).Error
+	})
 }
 
 // Peer operations
-func (db *DB) AddPeer(url, adminEmail string) (*models.Peer, error) {
+func (db *DB) AddPeer(ctx context.Context, url, adminEmail string) (*models.Peer, error) {
 	peer := &models.Peer{
 		URL:        url,
 		AdminEmail: adminEmail,
 		Status:     "unknown",
 	}
 
-	if err := db.conn.Create(peer).Error; err != nil {
+	if err := db.conn.WithContext(ctx).Create(peer).Error; err != nil {
 		return nil, err
 	}
 
 	return peer, nil
 }
 
-func (db *DB) GetPeerByURL(url string) (*models.Peer, error) {
+func (db *DB) GetPeerByURL(ctx context.Context, url string) (*models.Peer, error) {
 	var peer models.Peer
-	err := db.conn.Where("url = ?", url).First(&peer).Error
+	err := db.conn.WithContext(ctx).Where("url = ?", url).First(&peer).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -124,22 +237,22 @@ func (db *DB) GetPeerByURL(url string) (*models.Peer, error) {
 	return &peer, nil
 }
 
-func (db *DB) GetAllPeers() ([]models.Peer, error) {
+func (db *DB) GetAllPeers(ctx context.Context) ([]models.Peer, error) {
 	var peers []models.Peer
-	err := db.conn.Find(&peers).Error
+	err := db.conn.WithContext(ctx).Find(&peers).Error
 	return peers, err
 }
 
-func (db *DB) UpdatePeer(id int, data map[string]interface{}) error {
-	return db.conn.Model(&models.Peer{}).Where("id = ?", id).Updates(data).Error
+func (db *DB) UpdatePeer(ctx context.Context, id int, data map[string]interface{}) error {
+	return db.conn.WithContext(ctx).Model(&models.Peer{}).Where("id = ?", id).Updates(data).Error
 }
 
-func (db *DB) DeletePeer(id int) error {
-	return db.conn.Delete(&models.Peer{}, id).Error
+func (db *DB) DeletePeer(ctx context.Context, id int) error {
+	return db.conn.WithContext(ctx).Delete(&models.Peer{}, id).Error
 }
 
 // Notification operations
-func (db *DB) AddNotification(serviceID, peerID *int, message string) (*models.Notification, error) {
+func (db *DB) AddNotification(ctx context.Context, serviceID, peerID *int, message string) (*models.Notification, error) {
 	var svcID, prID *uint
 	if serviceID != nil {
 		id := uint(*serviceID)
@@ -157,28 +270,28 @@ func (db *DB) AddNotification(serviceID, peerID *int, message string) (*models.N
 		Sent:      false,
 	}
 
-	if err := db.conn.Create(notification).Error; err != nil {
+	if err := db.conn.WithContext(ctx).Create(notification).Error; err != nil {
 		return nil, err
 	}
 
 	return notification, nil
 }
 
-func (db *DB) MarkNotificationSent(id int, sent bool, errorMsg *string) error {
-	return db.conn.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{
+func (db *DB) MarkNotificationSent(ctx context.Context, id int, sent bool, errorMsg *string) error {
+	return db.conn.WithContext(ctx).Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"sent":  sent,
 		"error": errorMsg,
 	}).Error
 }
 
-func (db *DB) GetPendingNotifications() ([]models.Notification, error) {
+func (db *DB) GetPendingNotifications(ctx context.Context) ([]models.Notification, error) {
 	var notifications []models.Notification
-	err := db.conn.Where("sent = ?", false).Order("created_at ASC").Find(&notifications).Error
+	err := db.conn.WithContext(ctx).Where("sent = ?", false).Order("created_at ASC").Find(&notifications).Error
 	return notifications, err
 }
 
 // Log operations
-func (db *DB) AddLog(serviceID, peerID *int, status string, responseTime *int, message *string) error {
+func (db *DB) AddLog(ctx context.Context, serviceID, peerID *int, status string, responseTime *int, message *string) error {
 	var svcID, prID *uint
 	if serviceID != nil {
 		id := uint(*serviceID)
@@ -197,27 +310,32 @@ func (db *DB) AddLog(serviceID, peerID *int, status string, responseTime *int, m
 		Message:      message,
 	}
 
-	return db.conn.Create(log).Error
+	return db.AddLogEntry(ctx, log)
+}
+
+// AddLogEntry stores a fully populated log row, e.g. one carrying timings.
+func (db *DB) AddLogEntry(ctx context.Context, log *models.Log) error {
+	return db.conn.WithContext(ctx).Create(log).Error
 }
 
-func (db *DB) CleanupOldLogs(maxDays int) (int64, error) {
+func (db *DB) CleanupOldLogs(ctx context.Context, maxDays int) (int64, error) {
 	cutoff := time.Now().AddDate(0, 0, -maxDays)
-	result := db.conn.Where("created_at < ?", cutoff).Delete(&models.Log{})
+	result := db.conn.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&models.Log{})
 	return result.RowsAffected, result.Error
 }
 
-func (db *DB) GetServiceLogs(serviceID int, limit int) ([]models.Log, error) {
+func (db *DB) GetServiceLogs(ctx context.Context, serviceID int, limit int) ([]models.Log, error) {
 	var logs []models.Log
-	err := db.conn.Where("service_id = ?", serviceID).
+	err := db.conn.WithContext(ctx).Where("service_id = ?", serviceID).
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&logs).Error
 	return logs, err
 }
 
-func (db *DB) GetServiceLogsWithDateRange(serviceID int, startDate, endDate *time.Time, limit int) ([]models.Log, error) {
+func (db *DB) GetServiceLogsWithDateRange(ctx context.Context, serviceID int, startDate, endDate *time.Time, limit int) ([]models.Log, error) {
 	var logs []models.Log
-	query := db.conn.Where("service_id = ?", serviceID)
+	query := db.conn.WithContext(ctx).Where("service_id = ?", serviceID)
 
 	if startDate != nil {
 		query = query.Where("created_at >= ?", startDate)
@@ -234,17 +352,20 @@ func (db *DB) GetServiceLogsWithDateRange(serviceID int, startDate, endDate *tim
 }
 
 // Stats
-func (db *DB) GetServiceStats() (*models.ServiceStats, error) {
+func (db *DB) GetServiceStats(ctx context.Context) (*models.ServiceStats, error) {
 	var stats models.ServiceStats
-	var total, up, down, unknown int64
+	var total, up, degraded, down, unknown int64
 
-	db.conn.Model(&models.Service{}).Count(&total)
-	db.conn.Model(&models.Service{}).Where("status = ?", "up").Count(&up)
-	db.conn.Model(&models.Service{}).Where("status = ?", "down").Count(&down)
-	db.conn.Model(&models.Service{}).Where("status = ?", "unknown").Count(&unknown)
+	conn := db.conn.WithContext(ctx)
+	conn.Model(&models.Service{}).Count(&total)
+	conn.Model(&models.Service{}).Where("status = ?", "up").Count(&up)
+	conn.Model(&models.Service{}).Where("status = ?", "degraded").Count(&degraded)
+	conn.Model(&models.Service{}).Where("status = ?", "down").Count(&down)
+	conn.Model(&models.Service{}).Where("status = ?", "unknown").Count(&unknown)
 
 	stats.Total = int(total)
 	stats.Up = int(up)
+	stats.Degraded = int(degraded)
 	stats.Down = int(down)
 	stats.Unknown = int(unknown)
 
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := New(filepath.Join(t.TempDir(), "bjishk.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpsertService(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	url := "https://example.com/"
+	caregiver := "a@example.com"
+
+	created, err := db.UpsertService(ctx, url, 60, &caregiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Created || created.Restored || len(created.Changes) != 0 {
+		t.Fatalf("first upsert = %+v; want created without changes", created)
+	}
+
+	unchanged, err := db.UpsertService(ctx, url, 60, &caregiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.Created || unchanged.Restored || len(unchanged.Changes) != 0 || unchanged.Service.ID != created.Service.ID {
+		t.Fatalf("same settings = %+v; want no changes to service %d", unchanged, created.Service.ID)
+	}
+
+	newCaregiver := "b@example.com"
+	changed, err := db.UpsertService(ctx, url, 300, &newCaregiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantChanges := []string{`check_interval: 60 → 300`, `caregiver: "a@example.com" → "b@example.com"`}
+	if !reflect.DeepEqual(changed.Changes, wantChanges) {
+		t.Fatalf("changes = %q; want %q", changed.Changes, wantChanges)
+	}
+	if changed.Service.CheckInterval != 300 || *changed.Service.Caregiver != newCaregiver {
+		t.Fatalf("service = %+v; want the new settings", changed.Service)
+	}
+
+	if err := db.DeleteService(ctx, int(created.Service.ID)); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := db.UpsertService(ctx, url, 300, &newCaregiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Restored || restored.Created || restored.Service.ID != created.Service.ID {
+		t.Fatalf("re-added = %+v; want service %d restored", restored, created.Service.ID)
+	}
+	if restored.Service.Status != "unknown" || restored.Service.DeletedAt.Valid {
+		t.Fatalf("restored service = %+v; want an active row with unknown status", restored.Service)
+	}
+
+	services, err := db.GetAllServices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("got %d services, want 1", len(services))
+	}
+}
+
+func TestDeleteServiceReleasesPingToken(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	old, err := db.AddService(ctx, "heartbeat://a", 60, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateService(ctx, int(old.ID), map[string]interface{}{"ping_token": "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteService(ctx, int(old.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed, err := db.AddService(ctx, "heartbeat://b", 60, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateService(ctx, int(renamed.ID), map[string]interface{}{"ping_token": "token"}); err != nil {
+		t.Fatalf("reusing the token of a deleted patient: %v", err)
+	}
+
+	found, err := db.GetServiceByPingToken(ctx, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.ID != renamed.ID {
+		t.Fatalf("token resolves to %+v; want service %d", found, renamed.ID)
+	}
+}
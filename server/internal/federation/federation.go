@@ -1,13 +1,17 @@
 package federation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/yourusername/bjishk/internal/config"
 	"github.com/yourusername/bjishk/internal/database"
+	"github.com/yourusername/bjishk/internal/transport"
 	"github.com/yourusername/bjishk/pkg/models"
 )
 
@@ -18,6 +22,7 @@ type HealthResponse struct {
 	Uptime            int64  `json:"uptime"`
 	ServicesMonitored int    `json:"services_monitored"`
 	ServicesUp        int    `json:"services_up"`
+	ServicesDegraded  int    `json:"services_degraded"`
 	ServicesDown      int    `json:"services_down"`
 	Timestamp         string `json:"timestamp"`
 }
@@ -27,37 +32,58 @@ type Service struct {
 	config    FederationConfig
 	startTime time.Time
 	ticker    *time.Ticker
-	quit      chan struct{}
+	ctx       context.Context // Cancelled by StopMonitoring
+	cancel    context.CancelFunc
 	wg        sync.WaitGroup
 }
 
 type FederationConfig struct {
-	Retries         int
-	RetryDelay      int
+	Retries           int
+	RetryDelay        int
 	PeerCheckInterval int
+	Network           config.NetworkConfig
+	PeerTLS           map[string]config.TLSConfig // Keyed by peer URL without trailing slash
 }
 
 func New(db *database.DB, config FederationConfig) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Service{
 		db:        db,
 		config:    config,
 		startTime: time.Now(),
-		quit:      make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 }
 
-func (s *Service) CheckPeer(peer *models.Peer) (string, error) {
+// retryDelay waits between attempts and reports false when ctx is done first.
+func (s *Service) retryDelay(ctx context.Context) bool {
+	select {
+	case <-time.After(time.Duration(s.config.RetryDelay) * time.Second):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Service) CheckPeer(ctx context.Context, peer *models.Peer) (string, error) {
+	tr, err := transport.New(s.config.Network, s.config.PeerTLS[strings.TrimSuffix(peer.URL, "/")])
+	if err != nil {
+		return "down", err
+	}
+	defer tr.CloseIdleConnections()
+
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Transport: tr,
+		Timeout:   10 * time.Second,
 	}
 
 	for attempt := 0; attempt <= s.config.Retries; attempt++ {
 		healthURL := fmt.Sprintf("%s/api/health", peer.URL)
 
-		req, err := http.NewRequest("GET", healthURL, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", healthURL, nil)
 		if err != nil {
-			if attempt < s.config.Retries {
-				time.Sleep(time.Duration(s.config.RetryDelay) * time.Second)
+			if attempt < s.config.Retries && s.retryDelay(ctx) {
 				continue
 			}
 			return "down", err
@@ -67,8 +93,7 @@ func (s *Service) CheckPeer(peer *models.Peer) (string, error) {
 
 		resp, err := client.Do(req)
 		if err != nil {
-			if attempt < s.config.Retries {
-				time.Sleep(time.Duration(s.config.RetryDelay) * time.Second)
+			if attempt < s.config.Retries && s.retryDelay(ctx) {
 				continue
 			}
 			return "down", err
@@ -86,8 +111,7 @@ func (s *Service) CheckPeer(peer *models.Peer) (string, error) {
 			return "up", nil
 		}
 
-		if attempt < s.config.Retries {
-			time.Sleep(time.Duration(s.config.RetryDelay) * time.Second)
+		if attempt < s.config.Retries && s.retryDelay(ctx) {
 			continue
 		}
 
@@ -97,10 +121,13 @@ func (s *Service) CheckPeer(peer *models.Peer) (string, error) {
 	return "down", fmt.Errorf("all retries failed")
 }
 
-func (s *Service) PerformPeerCheck(peer *models.Peer) {
+func (s *Service) PerformPeerCheck(ctx context.Context, peer *models.Peer) {
 	fmt.Printf("🔍 Checking peer: %s\n", peer.URL)
 
-	status, err := s.CheckPeer(peer)
+	status, err := s.CheckPeer(ctx, peer)
+	if ctx.Err() != nil {
+		return
+	}
 	now := time.Now()
 
 	previousStatus := peer.Status
@@ -116,7 +143,7 @@ func (s *Service) PerformPeerCheck(peer *models.Peer) {
 	}
 
 	peerID := int(peer.ID)
-	if err := s.db.UpdatePeer(peerID, updateData); err != nil {
+	if err := s.db.UpdatePeer(ctx, peerID, updateData); err != nil {
 		fmt.Printf("   ❌ Failed to update peer: %v\n", err)
 		return
 	}
@@ -128,7 +155,7 @@ func (s *Service) PerformPeerCheck(peer *models.Peer) {
 		message = &msg
 	}
 
-	if err := s.db.AddLog(nil, &peerID, status, nil, message); err != nil {
+	if err := s.db.AddLog(ctx, nil, &peerID, status, nil, message); err != nil {
 		fmt.Printf("   ⚠️  Failed to add log: %v\n", err)
 	}
 
@@ -136,12 +163,12 @@ func (s *Service) PerformPeerCheck(peer *models.Peer) {
 	if previousStatus != status && status == "down" && consecutiveFailures >= 3 {
 		msg := fmt.Sprintf("Peer %s is DOWN (%d consecutive failures). Admin: %s",
 			peer.URL, consecutiveFailures, peer.AdminEmail)
-		if _, err := s.db.AddNotification(nil, &peerID, msg); err != nil {
+		if _, err := s.db.AddNotification(ctx, nil, &peerID, msg); err != nil {
 			fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
 		}
 	} else if previousStatus != status && status == "up" {
 		msg := fmt.Sprintf("Peer %s is back UP. Admin: %s", peer.URL, peer.AdminEmail)
-		if _, err := s.db.AddNotification(nil, &peerID, msg); err != nil {
+		if _, err := s.db.AddNotification(ctx, nil, &peerID, msg); err != nil {
 			fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
 		}
 	}
@@ -162,28 +189,31 @@ func (s *Service) StartMonitoring() {
 		defer s.wg.Done()
 
 		// Perform initial check
-		s.checkAllPeers()
+		s.checkAllPeers(s.ctx)
 
 		for {
 			select {
 			case <-s.ticker.C:
-				s.checkAllPeers()
-			case <-s.quit:
+				s.checkAllPeers(s.ctx)
+			case <-s.ctx.Done():
 				return
 			}
 		}
 	}()
 }
 
-func (s *Service) checkAllPeers() {
-	peers, err := s.db.GetAllPeers()
+func (s *Service) checkAllPeers(ctx context.Context) {
+	peers, err := s.db.GetAllPeers(ctx)
 	if err != nil {
 		fmt.Printf("❌ Failed to get peers: %v\n", err)
 		return
 	}
 
 	for i := range peers {
-		s.PerformPeerCheck(&peers[i])
+		if ctx.Err() != nil {
+			return
+		}
+		s.PerformPeerCheck(ctx, &peers[i])
 	}
 }
 
@@ -191,12 +221,12 @@ func (s *Service) StopMonitoring() {
 	if s.ticker != nil {
 		s.ticker.Stop()
 	}
-	close(s.quit)
+	s.cancel()
 	s.wg.Wait()
 }
 
-func (s *Service) GetHealthStatus(instanceName string) (*HealthResponse, error) {
-	stats, err := s.db.GetServiceStats()
+func (s *Service) GetHealthStatus(ctx context.Context, instanceName string) (*HealthResponse, error) {
+	stats, err := s.db.GetServiceStats(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +240,7 @@ func (s *Service) GetHealthStatus(instanceName string) (*HealthResponse, error)
 		Uptime:            uptime,
 		ServicesMonitored: stats.Total,
 		ServicesUp:        stats.Up,
+		ServicesDegraded:  stats.Degraded,
 		ServicesDown:      stats.Down,
 		Timestamp:         time.Now().Format(time.RFC3339),
 	}, nil
@@ -0,0 +1,152 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+// AddressResult is the outcome of a check pinned to one resolved address.
+type AddressResult struct {
+	Address      string `json:"address"`
+	Family       string `json:"family"`
+	Status       string `json:"status"`
+	ResponseTime int    `json:"response_time"`
+	Error        string `json:"error,omitempty"`
+}
+
+// checkAddresses resolves the target's host and checks each address (or one
+// address per family) separately. A patient that fails on some addresses but
+// works on others is degraded.
+func (m *Monitor) checkAddresses(ctx context.Context, prober Prober, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		return probe(ctx, prober, target)
+	}
+	if transport.Proxied(target.Network, target.Service.URL) {
+		// The proxy connects on our behalf, so pinning an address has no effect
+		return probe(ctx, prober, target)
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	addrs, err := transport.NewDialer(target.Network).LookupIPAddr(lookupCtx, host)
+	if err != nil || len(addrs) == 0 {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Lookup failed: %v", err)}
+	}
+	ips := selectAddresses(addrs, target.Patient.CheckAddresses)
+
+	results := make([]*CheckResult, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		pinned := *target
+		pinned.Network = pinAddress(target.Network, host, ip)
+
+		wg.Add(1)
+		go func(i int, pinned *Target) {
+			defer wg.Done()
+			results[i] = probe(ctx, prober, pinned)
+		}(i, &pinned)
+	}
+	wg.Wait()
+
+	return combineAddressResults(ips, results)
+}
+
+// pinAddress returns network settings that send connections to host to ip.
+// "host:port" overrides are dropped, as the dialer would prefer them.
+func pinAddress(network config.NetworkConfig, host string, ip net.IP) config.NetworkConfig {
+	resolve := make(map[string]string, len(network.Resolve)+1)
+	for key, address := range network.Resolve {
+		if keyHost, _, err := net.SplitHostPort(key); err == nil && keyHost == host {
+			continue
+		}
+		resolve[key] = address
+	}
+	resolve[host] = ip.String()
+
+	network.Resolve = resolve
+	return network
+}
+
+// selectAddresses returns every address for "all", otherwise the first
+// IPv4 and the first IPv6 address.
+func selectAddresses(addrs []net.IPAddr, mode string) []net.IP {
+	var ips []net.IP
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		family := addressFamily(addr.IP)
+		if mode != "all" && seen[family] {
+			continue
+		}
+		seen[family] = true
+		ips = append(ips, addr.IP)
+	}
+	return ips
+}
+
+func addressFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// combineAddressResults merges per-address results into one. The first
+// healthy result provides the response details.
+func combineAddressResults(ips []net.IP, results []*CheckResult) *CheckResult {
+	var combined *CheckResult
+	var failures []string
+	addresses := make([]AddressResult, len(results))
+	degraded := false
+
+	for i, result := range results {
+		addresses[i] = AddressResult{
+			Address:      ips[i].String(),
+			Family:       addressFamily(ips[i]),
+			Status:       result.Status,
+			ResponseTime: result.ResponseTime,
+			Error:        result.Error,
+		}
+
+		switch result.Status {
+		case "up":
+			if combined == nil {
+				combined = result
+			}
+		case "degraded":
+			degraded = true
+			if combined == nil {
+				combined = result
+			}
+		default:
+			failures = append(failures, fmt.Sprintf("%s (%s): %s", addressFamily(ips[i]), ips[i], result.Error))
+		}
+	}
+
+	if combined == nil {
+		// Every address failed
+		combined = results[0]
+		combined.Error = strings.Join(failures, "; ")
+	} else if len(failures) > 0 {
+		combined.Status = "degraded"
+		combined.Error = strings.Join(failures, "; ")
+	} else if degraded {
+		combined.Status = "degraded"
+	}
+
+	combined.Addresses = addresses
+
+	return combined
+}
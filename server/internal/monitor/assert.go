@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+
+	"github.com/yourusername/bjishk/internal/config"
+)
+
+// readBody reads at most limit bytes and reports an error if the body is
+// larger. A limit of 0 means unlimited.
+func readBody(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("Body exceeds max_body_size (%d bytes)", limit)
+	}
+	return body, nil
+}
+
+func hasAssertions(a *config.Assertions) bool {
+	return len(a.MustContain) > 0 || len(a.MustNotContain) > 0 ||
+		len(a.BodyRegex) > 0 || len(a.JSONPath) > 0 || a.MaxBodySize > 0
+}
+
+// checkAssertions returns a description of the first failing assertion.
+func checkAssertions(a *config.Assertions, body []byte) error {
+	for _, s := range a.MustContain {
+		if !bytes.Contains(body, []byte(s)) {
+			return fmt.Errorf("Assertion failed: body does not contain %q", s)
+		}
+	}
+
+	for _, s := range a.MustNotContain {
+		if bytes.Contains(body, []byte(s)) {
+			return fmt.Errorf("Assertion failed: body contains %q", s)
+		}
+	}
+
+	for _, pattern := range a.BodyRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("Assertion failed: invalid regex %q: %v", pattern, err)
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("Assertion failed: body does not match %q", pattern)
+		}
+	}
+
+	if len(a.JSONPath) == 0 {
+		return nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("Assertion failed: body is not valid JSON: %v", err)
+	}
+
+	for _, assertion := range a.JSONPath {
+		value, err := evalJSONPath(doc, assertion.Path)
+		if err != nil {
+			return fmt.Errorf("Assertion failed: %s: %v", assertion.Path, err)
+		}
+		if assertion.Value != nil && !jsonEqual(value, assertion.Value) {
+			return fmt.Errorf("Assertion failed: %s is %v, expected %v", assertion.Path, value, assertion.Value)
+		}
+	}
+
+	return nil
+}
+
+// jsonEqual compares a decoded JSON value with a value decoded from TOML.
+// Both are normalised through encoding/json so that e.g. TOML int64 and
+// JSON float64 compare equal.
+func jsonEqual(actual, expected interface{}) bool {
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	var normalised interface{}
+	if err := json.Unmarshal(data, &normalised); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(actual, normalised)
+}
@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/yourusername/bjishk/pkg/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxSnapshotSize caps the normalised content kept for diffing.
+	maxSnapshotSize = 64 * 1024
+	// maxDiffLines caps the diff excerpt included in notifications.
+	maxDiffLines = 40
+)
+
+// extractContent returns the normalised part of body watched for changes.
+// The selector is "css:<selector>", "regex:<pattern>" or empty for the
+// whole body.
+func extractContent(body []byte, selector string) (string, error) {
+	kind, expr, _ := strings.Cut(selector, ":")
+
+	var fragments []string
+	switch kind {
+	case "":
+		fragments = []string{string(body)}
+	case "css":
+		sel, err := cascadia.Compile(expr)
+		if err != nil {
+			return "", fmt.Errorf("invalid CSS selector %q: %v", expr, err)
+		}
+		doc, err := html.Parse(bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse HTML: %v", err)
+		}
+		for _, node := range cascadia.QueryAll(doc, sel) {
+			var buf bytes.Buffer
+			if err := html.Render(&buf, node); err == nil {
+				fragments = append(fragments, buf.String())
+			}
+		}
+	case "regex":
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex %q: %v", expr, err)
+		}
+		for _, match := range re.FindAll(body, -1) {
+			fragments = append(fragments, string(match))
+		}
+	default:
+		return "", fmt.Errorf("unknown change selector %q", selector)
+	}
+
+	if len(fragments) == 0 {
+		return "", fmt.Errorf("change selector %q matched nothing", selector)
+	}
+
+	return normaliseContent(strings.Join(fragments, "\n")), nil
+}
+
+// normaliseContent trims lines, collapses whitespace and drops blank lines
+// so that reformatting alone does not count as a change.
+func normaliseContent(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// contentUpdates stores the content hash on the service and queues a
+// "content changed" notification with a diff excerpt when it changes.
+func (m *Monitor) contentUpdates(ctx context.Context, service *models.Service, content string, updateData map[string]interface{}) {
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	if service.ContentHash != nil && *service.ContentHash == hash {
+		return
+	}
+
+	snapshot := content
+	if len(snapshot) > maxSnapshotSize {
+		// Cut before a character rather than through it
+		n := maxSnapshotSize
+		for n > 0 && !utf8.RuneStart(snapshot[n]) {
+			n--
+		}
+		snapshot = snapshot[:n]
+	}
+	updateData["content_hash"] = hash
+	updateData["content_snapshot"] = snapshot
+
+	// First check only records the baseline
+	if service.ContentHash == nil {
+		return
+	}
+
+	previous := ""
+	if service.ContentSnapshot != nil {
+		previous = *service.ContentSnapshot
+	}
+
+	msg := fmt.Sprintf("Content of %s has CHANGED.\n\n%s", service.URL, diffExcerpt(previous, snapshot))
+	serviceID := int(service.ID)
+	if _, err := m.db.AddNotification(ctx, &serviceID, nil, msg); err != nil {
+		fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
+	}
+}
+
+func diffExcerpt(previous, current string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(previous),
+		B:        difflib.SplitLines(current),
+		FromFile: "previous",
+		ToFile:   "current",
+		Context:  2,
+	})
+	if err != nil || diff == "" {
+		return "(no diff available)"
+	}
+
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	if len(lines) > maxDiffLines {
+		omitted := len(lines) - maxDiffLines
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("... (%d more lines)", omitted))
+	}
+	return strings.Join(lines, "\n")
+}
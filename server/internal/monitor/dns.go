@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+type dnsProber struct{}
+
+func init() {
+	Register("dns", dnsProber{})
+}
+
+// Probe resolves dns://[resolver[:port]]/name?type=A and checks the answer
+// contains every expected record.
+func (dnsProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" {
+		return &CheckResult{Status: "down", Error: "Missing name in dns:// URL"}
+	}
+
+	recordType := strings.ToUpper(u.Query().Get("type"))
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	// Without a resolver in the URL, use the configured one
+	resolver := transport.NewDialer(target.Network).Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if u.Host != "" {
+		server := u.Host
+		if u.Port() == "" {
+			server = net.JoinHostPort(u.Hostname(), "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	records, err := lookup(ctx, resolver, recordType, name)
+	responseTime := int(time.Since(start).Milliseconds())
+	if err != nil {
+		return &CheckResult{Status: "down", ResponseTime: responseTime, Error: fmt.Sprintf("Lookup failed: %v", err)}
+	}
+	if len(records) == 0 {
+		return &CheckResult{Status: "down", ResponseTime: responseTime, Error: fmt.Sprintf("No %s records for %s", recordType, name)}
+	}
+
+	got := make(map[string]bool, len(records))
+	for _, record := range records {
+		got[normaliseRecord(record)] = true
+	}
+	for _, expected := range target.Patient.ExpectRecords {
+		if !got[normaliseRecord(expected)] {
+			sort.Strings(records)
+			return &CheckResult{
+				Status:       "down",
+				ResponseTime: responseTime,
+				Error:        fmt.Sprintf("Missing %s record %q (got: %s)", recordType, expected, strings.Join(records, ", ")),
+			}
+		}
+	}
+
+	return &CheckResult{
+		Status:       "up",
+		ResponseTime: responseTime,
+	}
+}
+
+func lookup(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	var records []string
+
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, cname)
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, mx.Host)
+		}
+	case "NS":
+		nss, err := resolver.LookupNS(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			records = append(records, ns.Host)
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+
+	return records, nil
+}
+
+func normaliseRecord(record string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(record)), ".")
+}
@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pluginWaitDelay bounds how long a finished or killed plugin's output is
+// waited for, in case a child it left behind keeps stdout open.
+const pluginWaitDelay = time.Second
+
+// execProber runs a local Nagios-compatible plugin. Exit codes map to
+// statuses: 0 up, 1 degraded, 2 down, 3 (or anything else) unknown.
+type execProber struct{}
+
+func init() {
+	Register("exec", execProber{})
+}
+
+func (execProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	command := target.Patient.Command
+	if len(command) == 0 {
+		return &CheckResult{Status: "unknown", Error: "Missing command for exec patient"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.WaitDelay = pluginWaitDelay
+	killProcessGroup(cmd)
+
+	start := time.Now()
+	err := cmd.Run()
+	responseTime := int(time.Since(start).Milliseconds())
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// The plugin exited successfully, only its output was held open
+		err = nil
+	}
+
+	exited := cmd.ProcessState != nil && cmd.ProcessState.Exited()
+	if ctx.Err() == context.DeadlineExceeded && !exited {
+		return &CheckResult{
+			Status:       "down",
+			ResponseTime: responseTime,
+			Error:        fmt.Sprintf("Plugin timed out after %s", target.Timeout),
+		}
+	}
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return &CheckResult{Status: "unknown", Error: fmt.Sprintf("Failed to run plugin: %v", err)}
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	output, metrics := parsePluginOutput(stdout.String())
+	result := &CheckResult{
+		ResponseTime: responseTime,
+		Metrics:      metrics,
+	}
+
+	switch exitCode {
+	case 0:
+		result.Status = "up"
+		result.Message = output
+	case 1:
+		result.Status = "degraded"
+		result.Error = output
+	case 2:
+		result.Status = "down"
+		result.Error = output
+	default:
+		result.Status = "unknown"
+		result.Error = output
+	}
+
+	if result.Status != "up" && result.Error == "" {
+		result.Error = fmt.Sprintf("Plugin exited with code %d", exitCode)
+	}
+
+	return result
+}
+
+// parsePluginOutput returns the first line of plugin output (without
+// performance data) and the performance data from all lines.
+func parsePluginOutput(stdout string) (string, map[string]float64) {
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+
+	var message string
+	metrics := make(map[string]float64)
+	for i, line := range lines {
+		text, perf, _ := strings.Cut(line, "|")
+		if i == 0 {
+			message = strings.TrimSpace(text)
+		}
+		for label, value := range parsePerfData(perf) {
+			metrics[label] = value
+		}
+	}
+
+	if len(metrics) == 0 {
+		metrics = nil
+	}
+	return message, metrics
+}
+
+var perfDataRegex = regexp.MustCompile(`('[^']+'|[^\s=']+)=([-+]?[0-9.]+(?:[eE][-+]?[0-9]+)?)`)
+
+// parsePerfData parses "label=value[UOM];warn;crit;min;max" entries; only
+// the value is kept.
+func parsePerfData(perf string) map[string]float64 {
+	metrics := make(map[string]float64)
+	for _, match := range perfDataRegex.FindAllStringSubmatch(perf, -1) {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		metrics[strings.Trim(match[1], "'")] = value
+	}
+	return metrics
+}
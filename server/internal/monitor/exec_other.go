@@ -0,0 +1,9 @@
+//go:build !unix
+
+package monitor
+
+import "os/exec"
+
+// killProcessGroup is a no-op without Unix process groups; WaitDelay still
+// bounds how long a plugin's children can hold up the check.
+func killProcessGroup(cmd *exec.Cmd) {}
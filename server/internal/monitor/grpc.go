@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// grpcProber calls grpc.health.v1.Health/Check. grpc:// is plaintext,
+// grpcs:// uses TLS; the optional URL path is the service name to check.
+type grpcProber struct{}
+
+func init() {
+	Register("grpc", grpcProber{})
+	Register("grpcs", grpcProber{})
+}
+
+func (grpcProber) PerAddress() bool {
+	return true
+}
+
+func (grpcProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+	if u.Port() == "" {
+		return &CheckResult{Status: "down", Error: "Missing port in gRPC URL"}
+	}
+
+	creds := insecure.NewCredentials()
+	if u.Scheme == "grpcs" {
+		tlsConfig, err := transport.ClientTLS(target.Patient.TLSConfig)
+		if err != nil {
+			return &CheckResult{Status: "unknown", Error: fmt.Sprintf("Invalid connection settings: %v", err)}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	// passthrough hands the hostname to the dialer, which applies the
+	// resolver and resolve overrides (and per-address pinning)
+	conn, err := grpc.NewClient("passthrough:///"+u.Host,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent("Bjishk Health Monitor/1.0"),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return transport.NewDialer(target.Network).DialContext(ctx, "tcp", addr)
+		}),
+	)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Failed to create client: %v", err)}
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	var p peer.Peer
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: strings.TrimPrefix(u.Path, "/"),
+	}, grpc.Peer(&p))
+	responseTime := int(time.Since(start).Milliseconds())
+
+	var cert *CertInfo
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		cert = certInfo(&tlsInfo.State)
+	}
+
+	if err != nil {
+		return &CheckResult{
+			Status:       "down",
+			ResponseTime: responseTime,
+			Error:        fmt.Sprintf("Health check failed: %v", err),
+			Certificate:  cert,
+		}
+	}
+
+	result := &CheckResult{
+		ResponseTime: responseTime,
+		Certificate:  cert,
+	}
+
+	switch resp.GetStatus() {
+	case healthpb.HealthCheckResponse_SERVING:
+		result.Status = "up"
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		result.Status = "down"
+		result.Error = "Health status NOT_SERVING"
+	default:
+		result.Status = "unknown"
+		result.Error = fmt.Sprintf("Health status %s", resp.GetStatus())
+	}
+
+	return result
+}
@@ -0,0 +1,91 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Heartbeat ping kinds stored in Service.LastPingStatus.
+const (
+	PingSuccess = "success"
+	PingStart   = "start"
+	PingFail    = "fail"
+)
+
+// heartbeatProber is the watchdog for push patients: it never contacts the
+// patient, it only checks whether the last ping arrived on time.
+type heartbeatProber struct{}
+
+func init() {
+	Register("heartbeat", heartbeatProber{})
+}
+
+func (heartbeatProber) Passive() bool {
+	return true
+}
+
+func (heartbeatProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	service := target.Service
+	period := time.Duration(target.Patient.Period) * time.Second
+	grace := time.Duration(target.Patient.Grace) * time.Second
+	now := time.Now()
+
+	if service.LastPing == nil {
+		if now.Sub(service.CreatedAt) > period+grace {
+			return &CheckResult{
+				Status: "down",
+				Error:  fmt.Sprintf("No ping received since %s", service.CreatedAt.Format(time.RFC3339)),
+			}
+		}
+		return &CheckResult{Status: "unknown", Error: "Waiting for first ping"}
+	}
+
+	lastPing := *service.LastPing
+	kind := PingSuccess
+	if service.LastPingStatus != nil {
+		kind = *service.LastPingStatus
+	}
+
+	switch kind {
+	case PingFail:
+		return &CheckResult{
+			Status: "down",
+			Error:  fmt.Sprintf("Job reported failure at %s", lastPing.Format(time.RFC3339)),
+		}
+	case PingStart:
+		// Without a grace time a started job gets a full period to finish
+		runTime := grace
+		if runTime <= 0 {
+			runTime = period
+		}
+		if now.Sub(lastPing) > runTime {
+			return &CheckResult{
+				Status: "down",
+				Error:  fmt.Sprintf("Job started at %s but did not finish within %s", lastPing.Format(time.RFC3339), runTime),
+			}
+		}
+		return &CheckResult{Status: "up"}
+	}
+
+	if late := now.Sub(lastPing); late > period+grace {
+		return &CheckResult{
+			Status: "down",
+			Error: fmt.Sprintf("Last ping %s ago, expected every %s",
+				late.Round(time.Second), period),
+		}
+	}
+
+	return &CheckResult{Status: "up"}
+}
+
+// NewPingToken returns a random token for a heartbeat ping URL.
+func NewPingToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
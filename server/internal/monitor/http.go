@@ -0,0 +1,229 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+type httpProber struct{}
+
+func init() {
+	Register("http", httpProber{})
+	Register("https", httpProber{})
+}
+
+func (httpProber) PerAddress() bool {
+	return true
+}
+
+func (httpProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	assertions := &target.Patient.Assertions
+
+	var isJSON, isHTML bool
+	fetched, failed := fetch(ctx, target, &target.Patient.HTTPRequest, func(resp *http.Response) bool {
+		contentType := resp.Header.Get("Content-Type")
+		isJSON = regexp.MustCompile(`application/json`).MatchString(contentType)
+		isHTML = regexp.MustCompile(`text/html`).MatchString(contentType)
+		return hasAssertions(assertions) || target.Patient.DetectChanges || isJSON || isHTML
+	})
+	if failed != nil {
+		return failed
+	}
+	body := fetched.body
+
+	var title string
+	if isJSON {
+		var healthResp struct {
+			InstanceName string `json:"instance_name"`
+			InstanceType string `json:"instance_type"`
+		}
+		if json.Unmarshal(body, &healthResp) == nil && healthResp.InstanceType == "bjishk" {
+			title = healthResp.InstanceName
+		}
+	} else if isHTML {
+		titleRegex := regexp.MustCompile(`<title[^>]*>([^<]+)</title>`)
+		matches := titleRegex.FindSubmatch(body)
+		if len(matches) > 1 {
+			title = string(matches[1])
+		}
+	}
+
+	result := fetched.result()
+	result.Title = title
+
+	if err := checkAssertions(assertions, body); err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+		return result
+	}
+
+	if target.Patient.DetectChanges {
+		content, err := extractContent(body, target.Patient.ChangeSelector)
+		if err != nil {
+			result.Status = "down"
+			result.Error = err.Error()
+			return result
+		}
+		result.Content = &content
+	}
+
+	return result
+}
+
+// fetchedResponse is a completed HTTP check request.
+type fetchedResponse struct {
+	body         []byte
+	responseTime int
+	certificate  *CertInfo
+	timings      *Timings
+}
+
+// result returns a healthy CheckResult carrying the response details.
+func (f *fetchedResponse) result() *CheckResult {
+	return &CheckResult{
+		Status:       "up",
+		ResponseTime: f.responseTime,
+		Certificate:  f.certificate,
+		Timings:      f.timings,
+	}
+}
+
+// fetch sends the request described by spec to the target, through its
+// network and TLS settings. The body is read, up to the patient's max body
+// size, when readWanted reports true for the response. A failed request or
+// a status the patient does not accept yields a result instead.
+func fetch(ctx context.Context, target *Target, spec *config.HTTPRequest, readWanted func(*http.Response) bool) (*fetchedResponse, *CheckResult) {
+	tr, err := transport.New(target.Network, target.Patient.TLSConfig)
+	if err != nil {
+		return nil, &CheckResult{
+			Status: "unknown",
+			Error:  fmt.Sprintf("Invalid connection settings: %v", err),
+		}
+	}
+	defer tr.CloseIdleConnections()
+
+	client := newHTTPClient(spec, tr, target.Timeout)
+
+	start := time.Now()
+
+	req, err := newHTTPRequest(ctx, spec, target.Service.URL)
+	if err != nil {
+		return nil, &CheckResult{
+			Status: "down",
+			Error:  fmt.Sprintf("Failed to create request: %v", err),
+		}
+	}
+
+	trace := &timingTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &CheckResult{
+			Status: "down",
+			Error:  fmt.Sprintf("Request failed: %v", err),
+		}
+	}
+	defer resp.Body.Close()
+
+	fetched := &fetchedResponse{
+		responseTime: int(time.Since(start).Milliseconds()),
+		certificate:  certInfo(resp.TLS),
+	}
+
+	if !target.Patient.AcceptsStatus(resp.StatusCode) {
+		return nil, &CheckResult{
+			Status:      "down",
+			Error:       fmt.Sprintf("HTTP %d %s", resp.StatusCode, resp.Status),
+			Certificate: fetched.certificate,
+			Timings:     trace.timings(time.Now()),
+		}
+	}
+
+	if readWanted(resp) {
+		fetched.body, err = readBody(resp.Body, target.Patient.MaxBodySize)
+		if err != nil {
+			return nil, &CheckResult{
+				Status:       "down",
+				ResponseTime: fetched.responseTime,
+				Error:        err.Error(),
+				Certificate:  fetched.certificate,
+				Timings:      trace.timings(time.Now()),
+			}
+		}
+	}
+
+	fetched.timings = trace.timings(time.Now())
+	return fetched, nil
+}
+
+func newHTTPClient(spec *config.HTTPRequest, transport http.RoundTripper, timeout time.Duration) *http.Client {
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	maxRedirects := spec.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = 10
+	}
+
+	if spec.FollowRedirects != nil && !*spec.FollowRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		}
+	}
+
+	return client
+}
+
+func newHTTPRequest(ctx context.Context, spec *config.HTTPRequest, url string) (*http.Request, error) {
+	method := strings.ToUpper(spec.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	var body io.Reader
+	if spec.Body != "" {
+		body = strings.NewReader(spec.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "Bjishk Health Monitor/1.0")
+	for name, value := range spec.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	if spec.BasicAuth != nil {
+		req.SetBasicAuth(spec.BasicAuth.Username, spec.BasicAuth.Password)
+	} else if spec.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+spec.BearerToken)
+	}
+
+	return req, nil
+}
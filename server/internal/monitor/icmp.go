@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/transport"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// icmpProber sends ICMP echo requests to icmp://host. It uses unprivileged
+// ping sockets (SOCK_DGRAM, see net.ipv4.ping_group_range on Linux) and
+// falls back to raw sockets when those are unavailable but the process is
+// privileged.
+type icmpProber struct{}
+
+func init() {
+	Register("icmp", icmpProber{})
+}
+
+func (icmpProber) PerAddress() bool {
+	return true
+}
+
+func (icmpProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+
+	probeStart := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	addrs, err := transport.NewDialer(target.Network).LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Lookup failed: %v", err)}
+	}
+	ip := addrs[0].IP
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			ip = addr.IP
+			break
+		}
+	}
+
+	conn, raw, err := listenICMP(ip.To4() == nil)
+	if err != nil {
+		return &CheckResult{Status: "unknown", Error: fmt.Sprintf("Cannot open ICMP socket: %v", err)}
+	}
+	defer conn.Close()
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	if raw {
+		dst = &net.IPAddr{IP: ip}
+	}
+
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	protocol := 1
+	if ip.To4() == nil {
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		protocol = 58
+	}
+
+	count := packetCount(target.Patient.Count)
+	perPacket := (target.Timeout - time.Since(probeStart)) / time.Duration(count)
+	id := os.Getpid() & 0xffff
+
+	var rtts []time.Duration
+	var lastErr error
+	buf := make([]byte, 1500)
+	for seq := 0; seq < count; seq++ {
+		msg := icmp.Message{
+			Type: echoType,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("bjishk")},
+		}
+		packet, err := msg.Marshal(nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		start := time.Now()
+		conn.SetDeadline(start.Add(perPacket))
+		if _, err := conn.WriteTo(packet, dst); err != nil {
+			lastErr = err
+			continue
+		}
+
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				lastErr = err
+				break
+			}
+			reply, err := icmp.ParseMessage(protocol, buf[:n])
+			if err != nil || reply.Type != replyType {
+				continue
+			}
+			echo, ok := reply.Body.(*icmp.Echo)
+			// Ping sockets rewrite the ID, so only raw sockets can match on it
+			if !ok || echo.Seq != seq || (raw && echo.ID != id) {
+				continue
+			}
+			rtts = append(rtts, time.Since(start))
+			break
+		}
+	}
+
+	return packetResult(count, rtts, lastErr)
+}
+
+func listenICMP(v6 bool) (*icmp.PacketConn, bool, error) {
+	network, address, rawNetwork := "udp4", "0.0.0.0", "ip4:icmp"
+	if v6 {
+		network, address, rawNetwork = "udp6", "::", "ip6:ipv6-icmp"
+	}
+
+	conn, err := icmp.ListenPacket(network, address)
+	if err == nil {
+		return conn, false, nil
+	}
+
+	rawConn, rawErr := icmp.ListenPacket(rawNetwork, address)
+	if rawErr != nil {
+		return nil, false, err
+	}
+	return rawConn, true, nil
+}
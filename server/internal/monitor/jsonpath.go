@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evalJSONPath resolves a simple JSONPath expression against a decoded JSON
+// document. Supported syntax is dotted keys, bracketed keys and array
+// indices: "$.data.items[0].name", "$['key with dots']", "status".
+func evalJSONPath(doc interface{}, path string) (interface{}, error) {
+	tokens, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("key %q not found", token)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, fmt.Errorf("expected array index, got %q", token)
+			}
+			if index < 0 {
+				index += len(node)
+			}
+			if index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("index %d out of range", index)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into %T with %q", current, token)
+		}
+	}
+
+	return current, nil
+}
+
+func splitJSONPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+
+	var tokens []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' in JSONPath")
+			}
+			token := strings.Trim(path[1:end], `'"`)
+			tokens = append(tokens, token)
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			tokens = append(tokens, path[:end])
+			path = path[end:]
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,254 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+// mailProber connects to an SMTP, IMAP or POP3 server, reads the greeting,
+// optionally upgrades with STARTTLS and issues a harmless command
+// (EHLO/CAPABILITY/CAPA) before quitting. Each step is timed.
+type mailProber struct {
+	protocol    string
+	defaultPort string
+	implicitTLS bool
+}
+
+func init() {
+	Register("smtp", mailProber{protocol: "smtp", defaultPort: "25"})
+	Register("smtps", mailProber{protocol: "smtp", defaultPort: "465", implicitTLS: true})
+	Register("imap", mailProber{protocol: "imap", defaultPort: "143"})
+	Register("imaps", mailProber{protocol: "imap", defaultPort: "993", implicitTLS: true})
+	Register("pop3", mailProber{protocol: "pop3", defaultPort: "110"})
+	Register("pop3s", mailProber{protocol: "pop3", defaultPort: "995", implicitTLS: true})
+}
+
+func (mailProber) PerAddress() bool {
+	return true
+}
+
+func (p mailProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = p.defaultPort
+	}
+
+	result := &CheckResult{
+		Status:  "up",
+		Metrics: make(map[string]float64),
+	}
+	start := time.Now()
+	fail := func(step string, err error) *CheckResult {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("%s failed: %v", step, err)
+		result.ResponseTime = int(time.Since(start).Milliseconds())
+		return result
+	}
+	timed := func(name string, fn func() error) error {
+		stepStart := time.Now()
+		err := fn()
+		result.Metrics[name+"_ms"] = float64(time.Since(stepStart).Milliseconds())
+		return err
+	}
+
+	var conn net.Conn
+	if err := timed("connect", func() (err error) {
+		conn, err = transport.NewDialer(target.Network).DialTimeout(ctx, "tcp", net.JoinHostPort(host, port), target.Timeout)
+		return err
+	}); err != nil {
+		return fail("Connect", err)
+	}
+	defer func() { conn.Close() }()
+	rawConn := conn
+	defer context.AfterFunc(ctx, func() { rawConn.Close() })()
+	conn.SetDeadline(start.Add(target.Timeout))
+
+	upgrade := func() error {
+		tlsConn, cert, err := upgradeTLS(conn, host, target.Patient.TLSConfig)
+		if tlsConn != nil {
+			conn = tlsConn
+			result.Certificate = cert
+		}
+		return err
+	}
+
+	if p.implicitTLS {
+		if err := timed("tls", upgrade); err != nil {
+			return fail("TLS", err)
+		}
+	}
+
+	session := &mailSession{protocol: p.protocol, text: textproto.NewConn(conn)}
+
+	if err := timed("greeting", session.greeting); err != nil {
+		return fail("Greeting", err)
+	}
+	if err := timed("command", session.command); err != nil {
+		return fail(session.commandName(), err)
+	}
+
+	if target.Patient.StartTLS && !p.implicitTLS {
+		if err := timed("starttls", func() error {
+			if err := session.startTLS(); err != nil {
+				return err
+			}
+			if err := upgrade(); err != nil {
+				return err
+			}
+			session.text = textproto.NewConn(conn)
+			return session.command()
+		}); err != nil {
+			return fail("STARTTLS", err)
+		}
+	}
+
+	timed("quit", session.quit)
+
+	result.ResponseTime = int(time.Since(start).Milliseconds())
+	return result
+}
+
+type mailSession struct {
+	protocol string
+	text     *textproto.Conn
+	tag      int
+}
+
+func (s *mailSession) commandName() string {
+	switch s.protocol {
+	case "smtp":
+		return "EHLO"
+	case "imap":
+		return "CAPABILITY"
+	default:
+		return "CAPA"
+	}
+}
+
+func (s *mailSession) greeting() error {
+	switch s.protocol {
+	case "smtp":
+		_, _, err := s.text.ReadResponse(220)
+		return err
+	case "imap":
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "* OK") && !strings.HasPrefix(line, "* PREAUTH") {
+			return fmt.Errorf("unexpected greeting %q", truncate(line, 120))
+		}
+		return nil
+	default:
+		return s.pop3Status()
+	}
+}
+
+func (s *mailSession) command() error {
+	switch s.protocol {
+	case "smtp":
+		return s.smtpCmd(250, "EHLO bjishk")
+	case "imap":
+		return s.imapCmd("CAPABILITY")
+	default:
+		if err := s.text.PrintfLine("CAPA"); err != nil {
+			return err
+		}
+		// CAPA is optional (RFC 2449); a -ERR still proves the server is
+		// answering commands.
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "+OK") {
+			_, err = s.text.ReadDotLines()
+			return err
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			return nil
+		}
+		return fmt.Errorf("unexpected reply %q", truncate(line, 120))
+	}
+}
+
+func (s *mailSession) startTLS() error {
+	switch s.protocol {
+	case "smtp":
+		return s.smtpCmd(220, "STARTTLS")
+	case "imap":
+		return s.imapCmd("STARTTLS")
+	default:
+		if err := s.text.PrintfLine("STLS"); err != nil {
+			return err
+		}
+		return s.pop3Status()
+	}
+}
+
+func (s *mailSession) quit() error {
+	switch s.protocol {
+	case "smtp":
+		return s.smtpCmd(221, "QUIT")
+	case "imap":
+		return s.imapCmd("LOGOUT")
+	default:
+		if err := s.text.PrintfLine("QUIT"); err != nil {
+			return err
+		}
+		return s.pop3Status()
+	}
+}
+
+func (s *mailSession) smtpCmd(expectCode int, cmd string) error {
+	if err := s.text.PrintfLine("%s", cmd); err != nil {
+		return err
+	}
+	_, _, err := s.text.ReadResponse(expectCode)
+	return err
+}
+
+func (s *mailSession) imapCmd(cmd string) error {
+	s.tag++
+	tag := fmt.Sprintf("b%d", s.tag)
+	if err := s.text.PrintfLine("%s %s", tag, cmd); err != nil {
+		return err
+	}
+
+	for {
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, tag+" ") {
+			continue
+		}
+		if strings.HasPrefix(line, tag+" OK") {
+			return nil
+		}
+		return fmt.Errorf("%s", truncate(strings.TrimPrefix(line, tag+" "), 120))
+	}
+}
+
+func (s *mailSession) pop3Status() error {
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("%s", truncate(line, 120))
+	}
+	return nil
+}
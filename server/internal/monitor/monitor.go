@@ -1,143 +1,201 @@
 package monitor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"regexp"
 	"sync"
 	"time"
 
+	"github.com/yourusername/bjishk/internal/config"
 	"github.com/yourusername/bjishk/internal/database"
 	"github.com/yourusername/bjishk/pkg/models"
 )
 
+// downThreshold is the number of consecutive failed checks after which a
+// patient's DOWN notification is sent.
+const downThreshold = 3
+
 type CheckResult struct {
 	Status       string
 	ResponseTime int
 	Title        string
 	Error        string
+	Message      string // Optional: log message for healthy results
+	Certificate  *CertInfo
+	Timings      *Timings
+	Metrics      map[string]float64
+	Content      *string // Normalised content watched for changes
+	Addresses    []AddressResult
 }
 
 type Monitor struct {
-	db     *database.DB
-	config MonitorConfig
-	timers map[uint]*time.Ticker
-	mu     sync.RWMutex
-	wg     sync.WaitGroup
-	quit   chan struct{}
+	db       *database.DB
+	config   MonitorConfig
+	patients map[string]config.PatientEntry
+	mu       sync.RWMutex
+	wg       sync.WaitGroup
+	ctx      context.Context // Cancelled by StopAll
+	cancel   context.CancelFunc
+
+	// Scheduler state, see scheduler.go
+	queue     checkQueue
+	checks    map[uint]*scheduledCheck
+	queueMu   sync.Mutex
+	wake      chan struct{}
+	startOnce sync.Once
+	stats     models.SchedulerStats
 }
 
 type MonitorConfig struct {
-	Retries    int
-	RetryDelay int
-	Timeout    int
+	Retries         int
+	RetryDelay      int
+	Timeout         int
+	CertWarningDays []int
+	Network         config.NetworkConfig
+	Workers         int // Concurrent checks
+	StartJitter     int // Seconds to spread initial checks over
 }
 
 func New(db *database.DB, config MonitorConfig) *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Monitor{
 		db:     db,
 		config: config,
-		timers: make(map[uint]*time.Ticker),
-		quit:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+		checks: make(map[uint]*scheduledCheck),
+		wake:   make(chan struct{}, 1),
 	}
 }
 
-func (m *Monitor) CheckService(service *models.Service) *CheckResult {
-	client := &http.Client{
-		Timeout: time.Duration(10) * time.Second,
+// CheckService performs one check attempt. Retrying a failed attempt is up
+// to the caller, see shouldRetry.
+func (m *Monitor) CheckService(ctx context.Context, service *models.Service) *CheckResult {
+	patient := m.patient(service.URL)
+
+	prober, err := ProberFor(service.URL, patient.Type)
+	if err != nil {
+		return &CheckResult{
+			Status: "unknown",
+			Error:  err.Error(),
+		}
 	}
 
-	for attempt := 0; attempt <= m.config.Retries; attempt++ {
-		start := time.Now()
+	timeout := m.config.Timeout
+	if patient.Timeout != nil {
+		timeout = *patient.Timeout
+	}
+	if timeout <= 0 {
+		timeout = 10
+	}
 
-		req, err := http.NewRequest("GET", service.URL, nil)
-		if err != nil {
-			if attempt < m.config.Retries {
-				time.Sleep(time.Duration(m.config.RetryDelay) * time.Second)
-				continue
-			}
-			return &CheckResult{
-				Status: "down",
-				Error:  fmt.Sprintf("Failed to create request: %v", err),
-			}
-		}
+	target := &Target{
+		Service: service,
+		Patient: patient,
+		Network: m.config.Network.Merge(patient.NetworkConfig),
+		Timeout: time.Duration(timeout) * time.Second,
+	}
 
-		req.Header.Set("User-Agent", "Bjishk Health Monitor/1.0")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			if attempt < m.config.Retries {
-				time.Sleep(time.Duration(m.config.RetryDelay) * time.Second)
-				continue
-			}
-			return &CheckResult{
-				Status: "down",
-				Error:  fmt.Sprintf("Request failed: %v", err),
-			}
-		}
+	if patient.CheckAddresses != "" && isPerAddress(prober) {
+		return m.checkAddresses(ctx, prober, target)
+	}
+	return probe(ctx, prober, target)
+}
+
+// probe performs one attempt against a target and applies its latency
+// thresholds.
+func probe(ctx context.Context, prober Prober, target *Target) *CheckResult {
+	result := prober.Probe(ctx, target)
+	applyLatencyThresholds(result, &target.Patient)
+	return result
+}
 
-		responseTime := int(time.Since(start).Milliseconds())
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			var title string
-			contentType := resp.Header.Get("Content-Type")
-
-			if regexp.MustCompile(`application/json`).MatchString(contentType) {
-				body, err := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				if err == nil {
-					var healthResp struct {
-						InstanceName string `json:"instance_name"`
-						InstanceType string `json:"instance_type"`
-					}
-					if json.Unmarshal(body, &healthResp) == nil && healthResp.InstanceType == "bjishk" {
-						title = healthResp.InstanceName
-					}
-				}
-			} else if regexp.MustCompile(`text/html`).MatchString(contentType) {
-				body, err := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				if err == nil {
-					titleRegex := regexp.MustCompile(`<title[^>]*>([^<]+)</title>`)
-					matches := titleRegex.FindSubmatch(body)
-					if len(matches) > 1 {
-						title = string(matches[1])
-					}
-				}
-			} else {
-				resp.Body.Close()
-			}
-
-			return &CheckResult{
-				Status:       "up",
-				ResponseTime: responseTime,
-				Title:        title,
-			}
+// shouldRetry reports whether a result is worth another attempt before it
+// is recorded: the patient, or one of its addresses, is down and the
+// patient is not passive.
+func (m *Monitor) shouldRetry(service *models.Service, result *CheckResult) bool {
+	if m.passive(service) {
+		return false
+	}
+	if result.Status == "down" {
+		return true
+	}
+	for _, address := range result.Addresses {
+		if address.Status == "down" {
+			return true
 		}
+	}
+	return false
+}
 
-		resp.Body.Close()
+// applyLatencyThresholds downgrades a healthy result whose response time
+// exceeds the patient's warning or critical latency.
+func applyLatencyThresholds(result *CheckResult, patient *config.PatientEntry) {
+	if result.Status != "up" {
+		return
+	}
 
-		if attempt < m.config.Retries {
-			time.Sleep(time.Duration(m.config.RetryDelay) * time.Second)
-			continue
-		}
+	if patient.CriticalLatency > 0 && result.ResponseTime >= patient.CriticalLatency {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("Response time %dms exceeds critical threshold %dms",
+			result.ResponseTime, patient.CriticalLatency)
+	} else if patient.WarningLatency > 0 && result.ResponseTime >= patient.WarningLatency {
+		result.Status = "degraded"
+		result.Error = fmt.Sprintf("Response time %dms exceeds warning threshold %dms",
+			result.ResponseTime, patient.WarningLatency)
+	}
+}
 
-		return &CheckResult{
-			Status: "down",
-			Error:  fmt.Sprintf("HTTP %d %s", resp.StatusCode, resp.Status),
-		}
+// failureThreshold is the number of consecutive failures that makes a
+// patient DOWN. Passive patients are not retried and are only checked once
+// they are already late, so their first failure counts.
+func (m *Monitor) failureThreshold(service *models.Service) int {
+	if m.passive(service) {
+		return 1
+	}
+	return downThreshold
+}
+
+func (m *Monitor) passive(service *models.Service) bool {
+	prober, err := ProberFor(service.URL, m.patient(service.URL).Type)
+	return err == nil && isPassive(prober)
+}
+
+// SetPatients replaces the per-patient settings used by CheckService.
+func (m *Monitor) SetPatients(patients []config.PatientEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.patients = make(map[string]config.PatientEntry, len(patients))
+	for _, patient := range patients {
+		m.patients[patient.URL] = patient
 	}
+}
+
+func (m *Monitor) patient(url string) config.PatientEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if patient, ok := m.patients[url]; ok {
+		return patient
+	}
+	return config.PatientEntry{URL: url}
+}
 
-	return &CheckResult{
-		Status: "down",
-		Error:  "All retries failed",
+// PerformCheck runs one check attempt and records its result.
+func (m *Monitor) PerformCheck(ctx context.Context, service *models.Service) {
+	result := m.CheckService(ctx, service)
+	if ctx.Err() != nil {
+		// Cancelled mid-check, the result says nothing about the patient
+		return
 	}
+	m.recordResult(ctx, service, result)
 }
 
-func (m *Monitor) PerformCheck(service *models.Service) {
-	result := m.CheckService(service)
+// recordResult stores a check result, logs it and queues notifications for
+// status changes.
+func (m *Monitor) recordResult(ctx context.Context, service *models.Service, result *CheckResult) {
 	now := time.Now()
 
 	previousStatus := service.Status
@@ -147,6 +205,8 @@ func (m *Monitor) PerformCheck(service *models.Service) {
 	statusEmoji := "✅"
 	if newStatus == "down" {
 		statusEmoji = "❌"
+	} else if newStatus == "degraded" {
+		statusEmoji = "🐢"
 	} else if newStatus == "unknown" {
 		statusEmoji = "⚠️"
 	}
@@ -178,90 +238,75 @@ func (m *Monitor) PerformCheck(service *models.Service) {
 		updateData["name"] = result.Title
 	}
 
+	if result.Certificate != nil {
+		m.certUpdates(ctx, service, result.Certificate, m.patient(service.URL).CertWarningDays, updateData)
+	}
+
+	if result.Content != nil {
+		m.contentUpdates(ctx, service, *result.Content, updateData)
+	}
+
 	serviceID := int(service.ID)
-	if err := m.db.UpdateService(serviceID, updateData); err != nil {
+	if err := m.db.UpdateService(ctx, serviceID, updateData); err != nil {
 		fmt.Printf("   ❌ Failed to update service: %v\n", err)
 		return
 	}
 
 	// Log the check
-	var message *string
+	svcID := service.ID
+	entry := &models.Log{
+		ServiceID: &svcID,
+		Status:    newStatus,
+	}
 	if result.Error != "" {
-		message = &result.Error
+		entry.Message = &result.Error
+	} else if result.Message != "" {
+		entry.Message = &result.Message
 	}
-	var responseTime *int
 	if result.ResponseTime > 0 {
-		responseTime = &result.ResponseTime
+		entry.ResponseTime = &result.ResponseTime
+	}
+	if t := result.Timings; t != nil {
+		entry.DNSTime = &t.DNS
+		entry.ConnectTime = &t.Connect
+		entry.TLSTime = &t.TLS
+		entry.TTFB = &t.TTFB
+		entry.TransferTime = &t.Transfer
+	}
+	if len(result.Metrics) > 0 {
+		if data, err := json.Marshal(result.Metrics); err == nil {
+			metrics := string(data)
+			entry.Metrics = &metrics
+		}
+	}
+	if len(result.Addresses) > 0 {
+		if data, err := json.Marshal(result.Addresses); err == nil {
+			addresses := string(data)
+			entry.Addresses = &addresses
+		}
 	}
 
-	if err := m.db.AddLog(&serviceID, nil, newStatus, responseTime, message); err != nil {
+	if err := m.db.AddLogEntry(ctx, entry); err != nil {
 		fmt.Printf("   ⚠️  Failed to add log: %v\n", err)
 	}
 
-	// Status change notification
-	if previousStatus != newStatus && newStatus == "down" && consecutiveFailures >= 3 {
+	// Status change notification. DOWN is sent once, when the failures reach
+	// the threshold, rather than on the transition itself.
+	if newStatus == "down" && consecutiveFailures == m.failureThreshold(service) {
 		msg := fmt.Sprintf("Service %s is DOWN (%d consecutive failures). Error: %s",
 			service.URL, consecutiveFailures, result.Error)
-		if _, err := m.db.AddNotification(&serviceID, nil, msg); err != nil {
+		if _, err := m.db.AddNotification(ctx, &serviceID, nil, msg); err != nil {
+			fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
+		}
+	} else if previousStatus != newStatus && newStatus == "degraded" {
+		msg := fmt.Sprintf("Service %s is DEGRADED. %s", service.URL, result.Error)
+		if _, err := m.db.AddNotification(ctx, &serviceID, nil, msg); err != nil {
 			fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
 		}
 	} else if previousStatus != newStatus && newStatus == "up" {
 		msg := fmt.Sprintf("Service %s is back UP (response time: %dms)", service.URL, result.ResponseTime)
-		if _, err := m.db.AddNotification(&serviceID, nil, msg); err != nil {
+		if _, err := m.db.AddNotification(ctx, &serviceID, nil, msg); err != nil {
 			fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
 		}
 	}
 }
-
-func (m *Monitor) StartMonitoring(service *models.Service) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Stop existing timer if any
-	if ticker, exists := m.timers[service.ID]; exists {
-		ticker.Stop()
-	}
-
-	interval := time.Duration(service.CheckInterval) * time.Second
-	ticker := time.NewTicker(interval)
-	m.timers[service.ID] = ticker
-
-	m.wg.Add(1)
-	go func(svc *models.Service) {
-		defer m.wg.Done()
-
-		// Perform initial check
-		m.PerformCheck(svc)
-
-		for {
-			select {
-			case <-ticker.C:
-				// Refresh service data
-				refreshed, err := m.db.GetService(int(svc.ID))
-				if err != nil {
-					fmt.Printf("Failed to refresh service %d: %v\n", svc.ID, err)
-					continue
-				}
-				if refreshed != nil {
-					m.PerformCheck(refreshed)
-				}
-			case <-m.quit:
-				return
-			}
-		}
-	}(service)
-}
-
-func (m *Monitor) StopAll() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	close(m.quit)
-
-	for _, ticker := range m.timers {
-		ticker.Stop()
-	}
-
-	m.wg.Wait()
-	fmt.Println("🛑 All monitors stopped")
-}
@@ -0,0 +1,264 @@
+package monitor
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/database"
+	"github.com/yourusername/bjishk/pkg/models"
+)
+
+func newTestMonitor(t *testing.T, patient config.PatientEntry) (*Monitor, *database.DB, *models.Service) {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "bjishk.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	caregiver := "caregiver@example.com"
+	service, err := db.AddService(context.Background(), patient.URL, 60, &caregiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(db, MonitorConfig{Timeout: 5})
+	m.SetPatients([]config.PatientEntry{patient})
+	return m, db, service
+}
+
+// performChecks runs n checks, reloading the service between them like the
+// scheduler does.
+func performChecks(t *testing.T, m *Monitor, db *database.DB, service *models.Service, n int) *models.Service {
+	t.Helper()
+
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		m.PerformCheck(ctx, service)
+		refreshed, err := db.GetService(ctx, int(service.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		service = refreshed
+	}
+	return service
+}
+
+func pendingNotifications(t *testing.T, db *database.DB, contains string) int {
+	t.Helper()
+
+	notifications, err := db.GetPendingNotifications(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, notif := range notifications {
+		if strings.Contains(notif.Message, contains) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestCriticalLatencyNotifiesOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	m, db, service := newTestMonitor(t, config.PatientEntry{URL: srv.URL, CriticalLatency: 10})
+	service = performChecks(t, m, db, service, 6)
+
+	if service.Status != "down" || service.ConsecutiveFailures != 6 {
+		t.Fatalf("status = %s, failures = %d; want down, 6", service.Status, service.ConsecutiveFailures)
+	}
+	if n := pendingNotifications(t, db, "is DOWN"); n != 1 {
+		t.Fatalf("got %d DOWN notifications, want 1", n)
+	}
+}
+
+func TestLatePingNotifiesOnce(t *testing.T) {
+	m, db, service := newTestMonitor(t, config.PatientEntry{URL: "heartbeat://nightly-backup", Period: 60})
+
+	lastPing := time.Now().Add(-10 * time.Minute)
+	if err := db.UpdateService(context.Background(), int(service.ID), map[string]interface{}{
+		"last_ping":        lastPing,
+		"last_ping_status": PingSuccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	service.LastPing = &lastPing
+
+	service = performChecks(t, m, db, service, 6)
+
+	if service.Status != "down" || service.ConsecutiveFailures != 6 {
+		t.Fatalf("status = %s, failures = %d; want down, 6", service.Status, service.ConsecutiveFailures)
+	}
+	if n := pendingNotifications(t, db, "is DOWN"); n != 1 {
+		t.Fatalf("got %d DOWN notifications, want 1", n)
+	}
+}
+
+func TestRestoredHeartbeatIsNotLate(t *testing.T) {
+	m, db, service := newTestMonitor(t, config.PatientEntry{URL: "heartbeat://job", Period: 3600})
+	ctx := context.Background()
+
+	if err := db.UpdateService(ctx, int(service.ID), map[string]interface{}{
+		"last_ping":        time.Now().Add(-48 * time.Hour),
+		"last_ping_status": PingSuccess,
+		"created_at":       time.Now().Add(-72 * time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteService(ctx, int(service.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	caregiver := "caregiver@example.com"
+	upsert, err := db.UpsertService(ctx, service.URL, 60, &caregiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upsert.Restored || upsert.Service.ID != service.ID {
+		t.Fatalf("restored = %v, id = %d; want the soft-deleted row %d", upsert.Restored, upsert.Service.ID, service.ID)
+	}
+
+	service = performChecks(t, m, db, upsert.Service, 1)
+
+	if service.Status == "down" {
+		t.Fatalf("restored heartbeat is down right away")
+	}
+	if n := pendingNotifications(t, db, "is DOWN"); n != 0 {
+		t.Fatalf("got %d DOWN notifications, want 0", n)
+	}
+}
+
+// dispatchedCheck adds service to the schedule as if the dispatcher had just
+// handed it to a worker.
+func dispatchedCheck(m *Monitor, service *models.Service) *scheduledCheck {
+	check := &scheduledCheck{
+		service:  service,
+		interval: checkInterval(service),
+		due:      time.Now(),
+		index:    -1,
+		initial:  true,
+	}
+	m.queueMu.Lock()
+	m.checks[service.ID] = check
+	m.queueMu.Unlock()
+	return check
+}
+
+func serviceLogs(t *testing.T, db *database.DB, service *models.Service) int {
+	t.Helper()
+
+	logs, err := db.GetServiceLogs(context.Background(), int(service.ID), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(logs)
+}
+
+func TestRetryIsQueuedWithoutLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	m, db, service := newTestMonitor(t, config.PatientEntry{URL: srv.URL})
+	m.config.Retries = 1
+	m.config.RetryDelay = 3600
+	check := dispatchedCheck(m, service)
+
+	m.runCheck(check)
+
+	if n := serviceLogs(t, db, service); n != 0 {
+		t.Fatalf("got %d log rows after the first attempt, want 0", n)
+	}
+	if check.attempt != 1 || check.index < 0 {
+		t.Fatalf("attempt = %d, index = %d; want the retry queued", check.attempt, check.index)
+	}
+	if wait := time.Until(check.due); wait < 59*time.Minute {
+		t.Fatalf("retry due in %v, want the retry delay", wait)
+	}
+
+	m.queueMu.Lock()
+	heap.Pop(&m.queue)
+	m.queueMu.Unlock()
+	m.runCheck(check)
+
+	if n := serviceLogs(t, db, service); n != 1 {
+		t.Fatalf("got %d log rows after the last attempt, want 1", n)
+	}
+	if check.attempt != 0 || !check.due.Equal(check.slot.Add(check.interval)) {
+		t.Fatalf("attempt = %d, due = %v; want the next slot", check.attempt, check.due)
+	}
+}
+
+func TestCheckNowWhileRunningReruns(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer srv.Close()
+
+	m, db, service := newTestMonitor(t, config.PatientEntry{URL: srv.URL})
+	check := dispatchedCheck(m, service)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.runCheck(check)
+	}()
+	<-started
+	m.CheckNow(service.ID)
+	close(release)
+	<-done
+
+	if n := serviceLogs(t, db, service); n != 1 {
+		t.Fatalf("got %d log rows, want 1", n)
+	}
+	if check.rerun || check.index < 0 {
+		t.Fatalf("rerun = %v, index = %d; want the check queued again", check.rerun, check.index)
+	}
+	if check.due.After(time.Now()) {
+		t.Fatalf("next check due in %v, want now", time.Until(check.due))
+	}
+}
+
+func TestUnscheduledCheckIsSkipped(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	m, db, service := newTestMonitor(t, config.PatientEntry{URL: srv.URL})
+	check := dispatchedCheck(m, service)
+	m.StopMonitoring(service.ID)
+
+	m.runCheck(check)
+
+	if requests != 0 {
+		t.Fatalf("patient was checked %d times, want 0", requests)
+	}
+	if n := serviceLogs(t, db, service); n != 0 {
+		t.Fatalf("got %d log rows, want 0", n)
+	}
+	if check.index >= 0 || len(m.queue) != 0 {
+		t.Fatalf("index = %d, queue length = %d; want nothing queued", check.index, len(m.queue))
+	}
+}
@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/pkg/models"
+)
+
+// Target is everything a prober needs to perform a single check attempt.
+type Target struct {
+	Service *models.Service
+	Patient config.PatientEntry
+	Network config.NetworkConfig // Global network settings merged with the patient's
+	Timeout time.Duration
+}
+
+// Prober performs one check attempt against a target. Retries, logging and
+// notifications are handled by the monitor. Probes must return promptly
+// once ctx is done.
+type Prober interface {
+	Probe(ctx context.Context, target *Target) *CheckResult
+}
+
+// Passive is implemented by probers that evaluate state recorded elsewhere
+// (e.g. heartbeat pings), so retrying them is pointless.
+type Passive interface {
+	Passive() bool
+}
+
+func isPassive(prober Prober) bool {
+	passive, ok := prober.(Passive)
+	return ok && passive.Passive()
+}
+
+// PerAddress is implemented by probers that connect through the target's
+// network settings, so a check can be pinned to a single resolved address.
+type PerAddress interface {
+	PerAddress() bool
+}
+
+func isPerAddress(prober Prober) bool {
+	perAddress, ok := prober.(PerAddress)
+	return ok && perAddress.PerAddress()
+}
+
+var (
+	probersMu sync.RWMutex
+	probers   = make(map[string]Prober)
+)
+
+// Register makes a prober available for a URL scheme or patient type.
+func Register(kind string, prober Prober) {
+	probersMu.Lock()
+	defer probersMu.Unlock()
+	probers[strings.ToLower(kind)] = prober
+}
+
+// ProberFor returns the prober for a patient. An explicit type takes
+// precedence over the URL scheme.
+func ProberFor(rawURL, kind string) (Prober, error) {
+	if kind == "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL: %w", err)
+		}
+		kind = u.Scheme
+	}
+
+	probersMu.RLock()
+	defer probersMu.RUnlock()
+
+	prober, ok := probers[strings.ToLower(kind)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported patient type: %q", kind)
+	}
+	return prober, nil
+}
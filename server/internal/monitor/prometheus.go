@@ -0,0 +1,229 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/bjishk/internal/config"
+)
+
+// prometheusProber scrapes a Prometheus text exposition endpoint and
+// evaluates metric expressions against it. A failing metric_assertions
+// expression marks the patient down, a failing metric_warnings expression
+// marks it degraded.
+type prometheusProber struct{}
+
+func init() {
+	Register("prometheus", prometheusProber{})
+}
+
+func (prometheusProber) PerAddress() bool {
+	return true
+}
+
+// sample is one series of a scrape.
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+var sampleLabelRegex = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*"((?:[^"\\]|\\.)*)"\s*,?`)
+
+func (prometheusProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	spec := target.Patient.HTTPRequest
+	if _, ok := spec.Headers["Accept"]; !ok {
+		headers := map[string]string{"Accept": "text/plain;version=0.0.4;q=1,*/*;q=0.1"}
+		for name, value := range spec.Headers {
+			headers[name] = value
+		}
+		spec.Headers = headers
+	}
+
+	fetched, failed := fetch(ctx, target, &spec, func(*http.Response) bool { return true })
+	if failed != nil {
+		return failed
+	}
+
+	result := fetched.result()
+	result.Metrics = make(map[string]float64)
+
+	samples, err := parseExposition(fetched.body)
+	if err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("Invalid metrics: %v", err)
+		return result
+	}
+
+	if failures := evalMetrics(target.Patient.MetricAssertions, samples, result.Metrics); len(failures) > 0 {
+		result.Status = "down"
+		result.Error = strings.Join(failures, "; ")
+		return result
+	}
+	if failures := evalMetrics(target.Patient.MetricWarnings, samples, result.Metrics); len(failures) > 0 {
+		result.Status = "degraded"
+		result.Error = strings.Join(failures, "; ")
+	}
+
+	return result
+}
+
+// evalMetrics evaluates expressions against a scrape, records the values of
+// the matched series in metrics and returns a message per failing
+// expression.
+func evalMetrics(expressions []string, samples []sample, metrics map[string]float64) []string {
+	var failures []string
+	for _, raw := range expressions {
+		expr, err := config.ParseMetricExpression(raw)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		matched := false
+		var failed *sample
+		for i := range samples {
+			s := &samples[i]
+			if !s.matches(expr) {
+				continue
+			}
+			matched = true
+			// NaN and Inf cannot be stored as JSON
+			if !math.IsNaN(s.value) && !math.IsInf(s.value, 0) {
+				metrics[s.String()] = s.value
+			}
+			if failed == nil && !expr.Holds(s.value) {
+				failed = s
+			}
+		}
+
+		switch {
+		case !matched:
+			failures = append(failures, fmt.Sprintf("Metric %s not found", selectorString(expr.Name, expr.Labels)))
+		case failed != nil:
+			failures = append(failures, fmt.Sprintf("%s = %s (expected %s %s)",
+				failed, formatSampleValue(failed.value), expr.Operator, formatSampleValue(expr.Value)))
+		}
+	}
+	return failures
+}
+
+func (s *sample) matches(expr *config.MetricExpression) bool {
+	if s.name != expr.Name {
+		return false
+	}
+	for name, value := range expr.Labels {
+		if s.labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *sample) String() string {
+	return selectorString(s.name, s.labels)
+}
+
+// selectorString formats a series as name{a="1",b="2"} with sorted labels.
+func selectorString(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, label := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", label, labels[label])
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatSampleValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// parseExposition parses the Prometheus text format (and the compatible
+// subset of OpenMetrics). Comments, HELP and TYPE lines are skipped.
+func parseExposition(body []byte) ([]sample, error) {
+	var samples []sample
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+// parseSample parses `name{label="value",...} value [timestamp]`.
+func parseSample(line string) (sample, error) {
+	s := sample{labels: make(map[string]string)}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return s, fmt.Errorf("missing value")
+	}
+	s.name = line[:end]
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " \t")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			label := sampleLabelRegex.FindStringSubmatch(rest)
+			if label == nil {
+				return s, fmt.Errorf("invalid labels for %s", s.name)
+			}
+			value, err := strconv.Unquote(`"` + label[2] + `"`)
+			if err != nil {
+				return s, fmt.Errorf("invalid label %s for %s: %w", label[1], s.name, err)
+			}
+			s.labels[label[1]] = value
+			rest = rest[len(label[0]):]
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return s, fmt.Errorf("missing value for %s", s.name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid value %q for %s", fields[0], s.name)
+	}
+	s.value = value
+
+	return s, nil
+}
@@ -0,0 +1,351 @@
+package monitor
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/yourusername/bjishk/pkg/models"
+)
+
+const (
+	defaultWorkers     = 10
+	defaultStartJitter = 30 * time.Second
+)
+
+// scheduledCheck is a patient in the check queue.
+type scheduledCheck struct {
+	service  *models.Service
+	interval time.Duration
+	due      time.Time
+	index    int       // Position in the queue, -1 while running or removed
+	removed  bool      // Set when the patient stopped being monitored
+	initial  bool      // The first run uses service as passed to StartMonitoring
+	attempt  int       // Retries made for the current slot
+	slot     time.Time // Due time of the first attempt for the current slot
+	rerun    bool      // CheckNow was called while the check was running
+}
+
+// checkQueue is a min-heap of checks ordered by due time.
+type checkQueue []*scheduledCheck
+
+func (q checkQueue) Len() int           { return len(q) }
+func (q checkQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q checkQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *checkQueue) Push(x interface{}) {
+	check := x.(*scheduledCheck)
+	check.index = len(*q)
+	*q = append(*q, check)
+}
+
+func (q *checkQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	check := old[n-1]
+	old[n-1] = nil
+	check.index = -1
+	*q = old[:n-1]
+	return check
+}
+
+// StartMonitoring schedules periodic checks for a service, replacing any
+// existing schedule for it. The first check runs after a random delay of
+// up to the start jitter so patients loaded together do not fire at once.
+func (m *Monitor) StartMonitoring(service *models.Service) {
+	m.startOnce.Do(m.startScheduler)
+
+	interval := checkInterval(service)
+	jitter := m.startJitter()
+	if jitter > interval {
+		jitter = interval
+	}
+	var delay time.Duration
+	if jitter > 0 {
+		delay = time.Duration(rand.Int63n(int64(jitter)))
+	}
+
+	m.queueMu.Lock()
+	m.unschedule(service.ID)
+	check := &scheduledCheck{
+		service:  service,
+		interval: interval,
+		due:      time.Now().Add(delay),
+		initial:  true,
+	}
+	m.checks[service.ID] = check
+	heap.Push(&m.queue, check)
+	m.queueMu.Unlock()
+
+	m.wakeScheduler()
+}
+
+// Reschedule applies a changed check interval to a monitored service. A
+// shorter interval pulls the next check forward; a longer one takes effect
+// after it. Services that are not monitored yet are started.
+func (m *Monitor) Reschedule(service *models.Service) {
+	interval := checkInterval(service)
+
+	m.queueMu.Lock()
+	check, exists := m.checks[service.ID]
+	if !exists {
+		m.queueMu.Unlock()
+		m.StartMonitoring(service)
+		return
+	}
+	if check.index >= 0 {
+		if due := time.Now().Add(interval); due.Before(check.due) {
+			check.due = due
+			heap.Fix(&m.queue, check.index)
+		}
+	}
+	check.interval = interval
+	m.queueMu.Unlock()
+
+	m.wakeScheduler()
+}
+
+// CheckNow moves a monitored service's next check forward to now, e.g. after
+// a heartbeat ping. A running check is repeated once it finishes, as it may
+// have read the service before the change.
+func (m *Monitor) CheckNow(serviceID uint) {
+	m.queueMu.Lock()
+	check, exists := m.checks[serviceID]
+	if !exists {
+		m.queueMu.Unlock()
+		return
+	}
+	if check.index >= 0 {
+		check.due = time.Now()
+		check.initial = false // Reload the service before checking
+		heap.Fix(&m.queue, check.index)
+	} else {
+		check.rerun = true
+	}
+	m.queueMu.Unlock()
+
+	m.wakeScheduler()
+}
+
+// StopMonitoring removes a service from the schedule. A check that is
+// already running is allowed to finish.
+func (m *Monitor) StopMonitoring(serviceID uint) {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+	m.unschedule(serviceID)
+}
+
+// unschedule removes a service from the queue. A check that is already
+// running finishes but is not rescheduled. queueMu must be held.
+func (m *Monitor) unschedule(serviceID uint) {
+	check, exists := m.checks[serviceID]
+	if !exists {
+		return
+	}
+	check.removed = true
+	if check.index >= 0 {
+		heap.Remove(&m.queue, check.index)
+	}
+	delete(m.checks, serviceID)
+}
+
+// StopAll cancels in-flight checks and retries and waits for the workers to
+// exit.
+func (m *Monitor) StopAll() {
+	m.cancel()
+	m.wg.Wait()
+	fmt.Println("🛑 All monitors stopped")
+}
+
+// SchedulerStats returns the current scheduler state and queue lag.
+func (m *Monitor) SchedulerStats() models.SchedulerStats {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+
+	stats := m.stats
+	stats.Workers = m.workers()
+	stats.Scheduled = len(m.checks)
+	return stats
+}
+
+func checkInterval(service *models.Service) time.Duration {
+	interval := time.Duration(service.CheckInterval) * time.Second
+	if interval <= 0 {
+		return time.Minute
+	}
+	return interval
+}
+
+func (m *Monitor) workers() int {
+	if m.config.Workers > 0 {
+		return m.config.Workers
+	}
+	return defaultWorkers
+}
+
+func (m *Monitor) startJitter() time.Duration {
+	if m.config.StartJitter > 0 {
+		return time.Duration(m.config.StartJitter) * time.Second
+	}
+	return defaultStartJitter
+}
+
+func (m *Monitor) wakeScheduler() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *Monitor) startScheduler() {
+	work := make(chan *scheduledCheck)
+
+	for i := 0; i < m.workers(); i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for {
+				select {
+				case check := <-work:
+					m.runCheck(check)
+				case <-m.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.dispatch(work)
+	}()
+}
+
+// dispatch hands due checks to the workers in due order.
+func (m *Monitor) dispatch(work chan<- *scheduledCheck) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		m.queueMu.Lock()
+		wait := time.Hour
+		var next *scheduledCheck
+		if len(m.queue) > 0 {
+			wait = time.Until(m.queue[0].due)
+			if wait <= 0 {
+				next = heap.Pop(&m.queue).(*scheduledCheck)
+			}
+		}
+		m.queueMu.Unlock()
+
+		if next != nil {
+			select {
+			case work <- next:
+				m.recordLag(time.Since(next.due))
+			case <-m.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-m.wake:
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Monitor) recordLag(lag time.Duration) {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+
+	lagMs := lag.Milliseconds()
+	m.stats.Running++
+	m.stats.Dispatched++
+	m.stats.LagLastMs = lagMs
+	if lagMs > m.stats.LagMaxMs {
+		m.stats.LagMaxMs = lagMs
+	}
+	m.stats.LagAvgMs += (float64(lagMs) - m.stats.LagAvgMs) / float64(m.stats.Dispatched)
+}
+
+// runCheck performs one scheduled check attempt and queues either a retry
+// or the next check.
+func (m *Monitor) runCheck(check *scheduledCheck) {
+	defer func() {
+		m.queueMu.Lock()
+		m.stats.Running--
+		m.queueMu.Unlock()
+	}()
+
+	// Unscheduled after dispatch picked it up
+	m.queueMu.Lock()
+	removed := check.removed
+	m.queueMu.Unlock()
+	if removed {
+		return
+	}
+
+	service := check.service
+	if !check.initial {
+		// Refresh service data
+		refreshed, err := m.db.GetService(m.ctx, int(service.ID))
+		if err != nil {
+			fmt.Printf("Failed to refresh service %d: %v\n", service.ID, err)
+		} else if refreshed != nil {
+			service = refreshed
+		}
+	}
+	check.initial = false
+	if check.attempt == 0 {
+		check.slot = check.due
+	}
+
+	result := m.CheckService(m.ctx, service)
+	if m.ctx.Err() != nil {
+		// Cancelled mid-check, the result says nothing about the patient
+		return
+	}
+	retry := check.attempt < m.config.Retries && m.shouldRetry(service, result)
+	if !retry {
+		m.recordResult(m.ctx, service, result)
+	}
+
+	m.queueMu.Lock()
+	if !check.removed {
+		now := time.Now()
+		if retry {
+			// Wait in the queue rather than holding a worker
+			check.attempt++
+			check.due = now.Add(time.Duration(m.config.RetryDelay) * time.Second)
+		} else {
+			check.attempt = 0
+			check.due = check.slot.Add(check.interval)
+			if check.due.Before(now) || check.rerun {
+				check.due = now
+			}
+			check.rerun = false
+		}
+		heap.Push(&m.queue, check)
+	}
+	m.queueMu.Unlock()
+
+	m.wakeScheduler()
+}
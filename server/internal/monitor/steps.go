@@ -0,0 +1,250 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+// stepsProber runs a multi-step HTTP transaction. The whole scenario yields
+// one status; each step's duration and request phases are recorded as
+// metrics.
+type stepsProber struct{}
+
+func init() {
+	Register("steps", stepsProber{})
+}
+
+func (stepsProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Failed to create cookie jar: %v", err)}
+	}
+
+	tr, err := transport.New(target.Network, target.Patient.TLSConfig)
+	if err != nil {
+		return &CheckResult{Status: "unknown", Error: fmt.Sprintf("Invalid connection settings: %v", err)}
+	}
+	defer tr.CloseIdleConnections()
+
+	vars := make(map[string]string)
+	result := &CheckResult{
+		Status:  "up",
+		Metrics: make(map[string]float64),
+	}
+	start := time.Now()
+
+	for i := range target.Patient.Steps {
+		step := target.Patient.Steps[i]
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step%d", i+1)
+		}
+
+		remaining := target.Timeout - time.Since(start)
+		if remaining <= 0 {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("Step %d (%s): scenario timed out", i+1, name)
+			break
+		}
+
+		stepStart := time.Now()
+		stepResult := runStep(ctx, &step, vars, jar, tr, remaining)
+		result.Metrics[name+"_ms"] = float64(time.Since(stepStart).Milliseconds())
+		if t := stepResult.Timings; t != nil {
+			result.Metrics[name+"_dns_ms"] = float64(t.DNS)
+			result.Metrics[name+"_connect_ms"] = float64(t.Connect)
+			result.Metrics[name+"_tls_ms"] = float64(t.TLS)
+			result.Metrics[name+"_ttfb_ms"] = float64(t.TTFB)
+			result.Metrics[name+"_transfer_ms"] = float64(t.Transfer)
+		}
+
+		if i == 0 {
+			result.Certificate = stepResult.Certificate
+		}
+		if stepResult.Status != "up" {
+			result.Status = stepResult.Status
+			result.Error = fmt.Sprintf("Step %d (%s): %s", i+1, name, stepResult.Error)
+			break
+		}
+	}
+
+	result.ResponseTime = int(time.Since(start).Milliseconds())
+	return result
+}
+
+func runStep(ctx context.Context, step *config.Step, vars map[string]string, jar http.CookieJar, transport http.RoundTripper, timeout time.Duration) *CheckResult {
+	spec, url, err := expandStep(step, vars)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: err.Error()}
+	}
+
+	client := newHTTPClient(spec, transport, timeout)
+	client.Jar = jar
+
+	req, err := newHTTPRequest(ctx, spec, url)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Failed to create request: %v", err)}
+	}
+
+	trace := &timingTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Request failed: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	result := &CheckResult{
+		Certificate: certInfo(resp.TLS),
+	}
+
+	if !spec.AcceptsStatus(resp.StatusCode) {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("HTTP %d %s", resp.StatusCode, resp.Status)
+		result.Timings = trace.timings(time.Now())
+		return result
+	}
+
+	body, err := readBody(resp.Body, step.MaxBodySize)
+	result.Timings = trace.timings(time.Now())
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := checkAssertions(&step.Assertions, body); err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+		return result
+	}
+
+	for name, source := range step.Capture {
+		value, err := capture(source, resp, body, jar)
+		if err != nil {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("Capture %s failed: %v", name, err)
+			return result
+		}
+		vars[name] = value
+	}
+
+	result.Status = "up"
+	return result
+}
+
+// capture extracts a value from a step response. Sources are
+// "json:<path>", "header:<name>", "cookie:<name>" and "regex:<pattern>"
+// (first group, or the whole match).
+func capture(source string, resp *http.Response, body []byte, jar http.CookieJar) (string, error) {
+	kind, expr, _ := strings.Cut(source, ":")
+
+	switch kind {
+	case "json":
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("body is not valid JSON: %v", err)
+		}
+		value, err := evalJSONPath(doc, expr)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		data, err := json.Marshal(value)
+		return string(data), err
+	case "header":
+		value := resp.Header.Get(expr)
+		if value == "" {
+			return "", fmt.Errorf("header %q not present", expr)
+		}
+		return value, nil
+	case "cookie":
+		for _, cookie := range jar.Cookies(resp.Request.URL) {
+			if cookie.Name == expr {
+				return cookie.Value, nil
+			}
+		}
+		return "", fmt.Errorf("cookie %q not set", expr)
+	case "regex":
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return "", err
+		}
+		matches := re.FindSubmatch(body)
+		if matches == nil {
+			return "", fmt.Errorf("body does not match %q", expr)
+		}
+		if len(matches) > 1 {
+			return string(matches[1]), nil
+		}
+		return string(matches[0]), nil
+	}
+
+	return "", fmt.Errorf("unknown capture source %q", source)
+}
+
+var templateVarRegex = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// expandStep substitutes {{name}} placeholders in the step's URL and
+// request fields with previously captured values. Values in the URL are
+// escaped for the path or the query string, depending on where they are.
+func expandStep(step *config.Step, vars map[string]string) (*config.HTTPRequest, string, error) {
+	var missing []string
+	expandEscaped := func(s string, escape func(string) string) string {
+		return templateVarRegex.ReplaceAllStringFunc(s, func(match string) string {
+			name := templateVarRegex.FindStringSubmatch(match)[1]
+			value, ok := vars[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return escape(value)
+		})
+	}
+	expand := func(s string) string {
+		return expandEscaped(s, func(value string) string { return value })
+	}
+
+	spec := step.HTTPRequest
+	url := step.URL
+	query := ""
+	if i := strings.Index(url, "?"); i >= 0 {
+		url, query = url[:i], url[i:]
+	}
+	url = expandEscaped(url, neturl.PathEscape) + expandEscaped(query, neturl.QueryEscape)
+	spec.Body = expand(spec.Body)
+	spec.BearerToken = expand(spec.BearerToken)
+
+	if spec.Headers != nil {
+		headers := make(map[string]string, len(spec.Headers))
+		for name, value := range spec.Headers {
+			headers[name] = expand(value)
+		}
+		spec.Headers = headers
+	}
+
+	if spec.BasicAuth != nil {
+		spec.BasicAuth = &config.BasicAuth{
+			Username: expand(spec.BasicAuth.Username),
+			Password: expand(spec.BasicAuth.Password),
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, "", fmt.Errorf("Undefined variable(s): %s", strings.Join(missing, ", "))
+	}
+	return &spec, url, nil
+}
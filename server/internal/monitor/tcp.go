@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+// maxReplySize caps how much of a reply is buffered while looking for the
+// expected pattern.
+const maxReplySize = 64 * 1024
+
+type tcpProber struct{}
+
+func init() {
+	Register("tcp", tcpProber{})
+}
+
+func (tcpProber) PerAddress() bool {
+	return true
+}
+
+func (tcpProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+	if u.Port() == "" {
+		return &CheckResult{Status: "down", Error: "Missing port in tcp:// URL"}
+	}
+
+	start := time.Now()
+	conn, err := transport.NewDialer(target.Network).DialTimeout(ctx, "tcp", u.Host, target.Timeout)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Connect failed: %v", err)}
+	}
+	defer conn.Close()
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	connectTime := int(time.Since(start).Milliseconds())
+	conn.SetDeadline(start.Add(target.Timeout))
+
+	send, err := payload(&target.Patient)
+	if err != nil {
+		return &CheckResult{Status: "down", ResponseTime: connectTime, Error: err.Error()}
+	}
+
+	if err := exchange(conn, send, target.Patient.Expect); err != nil {
+		return &CheckResult{Status: "down", ResponseTime: connectTime, Error: err.Error()}
+	}
+
+	return &CheckResult{
+		Status:       "up",
+		ResponseTime: connectTime,
+	}
+}
+
+// exchange writes the optional payload to conn and, when a pattern is given,
+// reads until the reply matches it.
+func exchange(conn net.Conn, send []byte, expect string) error {
+	if len(send) > 0 {
+		if _, err := conn.Write(send); err != nil {
+			return fmt.Errorf("Send failed: %v", err)
+		}
+	}
+
+	if expect == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(expect)
+	if err != nil {
+		return fmt.Errorf("Invalid expect pattern: %v", err)
+	}
+
+	var reply bytes.Buffer
+	buf := make([]byte, 4096)
+	for reply.Len() < maxReplySize {
+		n, err := conn.Read(buf)
+		reply.Write(buf[:n])
+		if pattern.Match(reply.Bytes()) {
+			return nil
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return fmt.Errorf("Reply did not match %q: %q", expect, truncate(reply.String(), 120))
+}
+
+// payload returns the bytes to send: send_hex when set, otherwise send.
+func payload(patient *config.PatientEntry) ([]byte, error) {
+	if patient.SendHex != "" {
+		data, err := hex.DecodeString(strings.ReplaceAll(patient.SendHex, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid send_hex: %v", err)
+		}
+		return data, nil
+	}
+	return []byte(patient.Send), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
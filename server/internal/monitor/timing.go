@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is a per-phase latency breakdown in milliseconds. The phases are
+// sequential so they can be stacked: DNS, then Connect, then TLS, then TTFB
+// (request written until first response byte), then Transfer (body read).
+type Timings struct {
+	DNS      int
+	Connect  int
+	TLS      int
+	TTFB     int
+	Transfer int
+}
+
+// timingTrace collects phase durations through net/http/httptrace. Phases
+// that happen more than once (redirects) are summed.
+type timingTrace struct {
+	mu                              sync.Mutex
+	dns, connect, tls, ttfb         time.Duration
+	dnsStart, connectStart          time.Time
+	tlsStart, wroteRequest, gotByte time.Time
+}
+
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.dns += time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			t.connectStart = time.Now()
+			t.mu.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			t.connect += time.Since(t.connectStart)
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.tls += time.Since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			t.wroteRequest = time.Now()
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.gotByte = time.Now()
+			t.ttfb += t.gotByte.Sub(t.wroteRequest)
+			t.mu.Unlock()
+		},
+	}
+}
+
+// timings returns the collected phases; transfer is measured from the first
+// response byte of the final response until done.
+func (t *timingTrace) timings(done time.Time) *Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var transfer time.Duration
+	if !t.gotByte.IsZero() {
+		transfer = done.Sub(t.gotByte)
+	}
+
+	return &Timings{
+		DNS:      int(t.dns.Milliseconds()),
+		Connect:  int(t.connect.Milliseconds()),
+		TLS:      int(t.tls.Milliseconds()),
+		TTFB:     int(t.ttfb.Milliseconds()),
+		Transfer: int(transfer.Milliseconds()),
+	}
+}
@@ -0,0 +1,210 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+	"github.com/yourusername/bjishk/internal/transport"
+	"github.com/yourusername/bjishk/pkg/models"
+)
+
+// CertInfo describes the leaf certificate presented by a patient.
+type CertInfo struct {
+	NotAfter time.Time
+	Issuer   string
+	SANs     []string
+}
+
+// DefaultCertWarningDays are the days-before-expiry at which an "expiring"
+// notification is sent when none are configured.
+var DefaultCertWarningDays = []int{30, 14, 3}
+
+type tlsProber struct{}
+
+func init() {
+	Register("tls", tlsProber{})
+}
+
+func (tlsProber) PerAddress() bool {
+	return true
+}
+
+func (tlsProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+	if u.Port() == "" {
+		return &CheckResult{Status: "down", Error: "Missing port in tls:// URL"}
+	}
+
+	start := time.Now()
+	rawConn, err := transport.NewDialer(target.Network).DialTimeout(ctx, "tcp", u.Host, target.Timeout)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Connect failed: %v", err)}
+	}
+	defer rawConn.Close()
+	defer context.AfterFunc(ctx, func() { rawConn.Close() })()
+	rawConn.SetDeadline(start.Add(target.Timeout))
+
+	conn, cert, err := upgradeTLS(rawConn, u.Hostname(), target.Patient.TLSConfig)
+	if conn == nil {
+		return &CheckResult{Status: "down", Error: err.Error()}
+	}
+
+	result := &CheckResult{
+		Status:       "up",
+		ResponseTime: int(time.Since(start).Milliseconds()),
+		Certificate:  cert,
+	}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// upgradeTLS performs a TLS handshake over conn. Verification is done by
+// hand after the handshake so the certificate is still returned when it is
+// expired or otherwise invalid; in that case both the connection and an
+// error are returned. A nil connection means the handshake itself failed.
+func upgradeTLS(conn net.Conn, serverName string, settings config.TLSConfig) (*tls.Conn, *CertInfo, error) {
+	tlsConfig, err := transport.ClientTLS(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid connection settings: %v", err)
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = serverName
+	}
+	verify := !tlsConfig.InsecureSkipVerify
+	tlsConfig.InsecureSkipVerify = true
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, nil, fmt.Errorf("TLS handshake failed: %v", err)
+	}
+
+	state := tlsConn.ConnectionState()
+	cert := certInfo(&state)
+	if !verify {
+		return tlsConn, cert, nil
+	}
+	if err := verifyChain(&state, tlsConfig.ServerName, tlsConfig.RootCAs); err != nil {
+		return tlsConn, cert, fmt.Errorf("Certificate verification failed: %v", err)
+	}
+	return tlsConn, cert, nil
+}
+
+// verifyChain verifies the peer chain against roots, or the system roots
+// when nil.
+func verifyChain(state *tls.ConnectionState, serverName string, roots *x509.CertPool) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("no peer certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Intermediates: intermediates,
+		Roots:         roots,
+	})
+	return err
+}
+
+func certInfo(state *tls.ConnectionState) *CertInfo {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	sans := append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	issuer := leaf.Issuer.CommonName
+	if issuer == "" {
+		issuer = leaf.Issuer.String()
+	}
+
+	return &CertInfo{
+		NotAfter: leaf.NotAfter,
+		Issuer:   issuer,
+		SANs:     sans,
+	}
+}
+
+// certUpdates stores the certificate details on the service and queues an
+// "expiring" notification each time a new warning threshold is crossed.
+func (m *Monitor) certUpdates(ctx context.Context, service *models.Service, cert *CertInfo, warningDays []int, updateData map[string]interface{}) {
+	updateData["cert_expires_at"] = cert.NotAfter
+	updateData["cert_issuer"] = cert.Issuer
+	updateData["cert_sans"] = strings.Join(cert.SANs, ",")
+
+	if len(warningDays) == 0 {
+		warningDays = m.config.CertWarningDays
+	}
+	if len(warningDays) == 0 {
+		warningDays = DefaultCertWarningDays
+	}
+
+	thresholds := append([]int{}, warningDays...)
+	sort.Ints(thresholds)
+
+	daysLeft := int(time.Until(cert.NotAfter).Hours() / 24)
+
+	crossed := -1
+	for _, days := range thresholds {
+		if daysLeft <= days {
+			crossed = days
+			break
+		}
+	}
+
+	if crossed < 0 {
+		// Renewed or not yet close to expiry
+		if service.CertNotifiedDays != nil {
+			updateData["cert_notified_days"] = nil
+		}
+		return
+	}
+
+	if service.CertNotifiedDays != nil && *service.CertNotifiedDays <= crossed {
+		return
+	}
+	updateData["cert_notified_days"] = crossed
+
+	var msg string
+	if daysLeft < 0 {
+		msg = fmt.Sprintf("Certificate for %s has EXPIRED (%s, issuer: %s)",
+			service.URL, cert.NotAfter.Format(time.RFC1123), cert.Issuer)
+	} else {
+		msg = fmt.Sprintf("Certificate for %s is expiring in %d day%s (%s, issuer: %s)",
+			service.URL, daysLeft, plural(daysLeft), cert.NotAfter.Format(time.RFC1123), cert.Issuer)
+	}
+
+	serviceID := int(service.ID)
+	if _, err := m.db.AddNotification(ctx, &serviceID, nil, msg); err != nil {
+		fmt.Printf("   ⚠️  Failed to create notification: %v\n", err)
+	}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
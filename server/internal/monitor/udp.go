@@ -0,0 +1,148 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+// defaultPacketCount is how many packets udp:// and icmp:// probes send
+// when no count is configured.
+const defaultPacketCount = 3
+
+// udpProber sends the configured payload and waits for any reply, or one
+// matching the expect pattern. Each packet gets an equal share of the
+// timeout; loss and round-trip times are recorded as metrics.
+type udpProber struct{}
+
+func init() {
+	Register("udp", udpProber{})
+}
+
+func (udpProber) PerAddress() bool {
+	return true
+}
+
+func (udpProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	u, err := url.Parse(target.Service.URL)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid URL: %v", err)}
+	}
+	if u.Port() == "" {
+		return &CheckResult{Status: "down", Error: "Missing port in udp:// URL"}
+	}
+
+	send, err := payload(&target.Patient)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: err.Error()}
+	}
+
+	var pattern *regexp.Regexp
+	if target.Patient.Expect != "" {
+		pattern, err = regexp.Compile(target.Patient.Expect)
+		if err != nil {
+			return &CheckResult{Status: "down", Error: fmt.Sprintf("Invalid expect pattern: %v", err)}
+		}
+	}
+
+	conn, err := transport.NewDialer(target.Network).DialTimeout(ctx, "udp", u.Host, target.Timeout)
+	if err != nil {
+		return &CheckResult{Status: "down", Error: fmt.Sprintf("Dial failed: %v", err)}
+	}
+	defer conn.Close()
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	count := packetCount(target.Patient.Count)
+	perPacket := target.Timeout / time.Duration(count)
+
+	var rtts []time.Duration
+	var lastErr error
+	buf := make([]byte, 65535)
+	for i := 0; i < count; i++ {
+		start := time.Now()
+		conn.SetDeadline(start.Add(perPacket))
+
+		if _, err := conn.Write(send); err != nil {
+			lastErr = err
+			continue
+		}
+
+		var mismatch error
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				lastErr = err
+				if mismatch != nil {
+					lastErr = mismatch
+				}
+				break
+			}
+			if pattern == nil || pattern.Match(buf[:n]) {
+				rtts = append(rtts, time.Since(start))
+				break
+			}
+			mismatch = fmt.Errorf("reply did not match %q: %q", target.Patient.Expect, truncate(string(buf[:n]), 120))
+		}
+	}
+
+	return packetResult(count, rtts, lastErr)
+}
+
+func packetCount(count int) int {
+	if count <= 0 {
+		return defaultPacketCount
+	}
+	return count
+}
+
+// packetResult turns per-packet round-trip times into a check result with
+// loss and RTT metrics. The patient is down only if every packet was lost.
+func packetResult(sent int, rtts []time.Duration, lastErr error) *CheckResult {
+	received := len(rtts)
+	metrics := map[string]float64{
+		"sent":     float64(sent),
+		"received": float64(received),
+		"loss_pct": float64(sent-received) * 100 / float64(sent),
+	}
+
+	if received == 0 {
+		msg := fmt.Sprintf("No reply to %d packet%s", sent, plural(sent))
+		var netErr net.Error
+		if lastErr != nil && !(errors.As(lastErr, &netErr) && netErr.Timeout()) {
+			msg = fmt.Sprintf("%s: %v", msg, lastErr)
+		}
+		return &CheckResult{Status: "down", Error: msg, Metrics: metrics}
+	}
+
+	fastest, slowest, total := rtts[0], rtts[0], time.Duration(0)
+	for _, rtt := range rtts {
+		if rtt < fastest {
+			fastest = rtt
+		}
+		if rtt > slowest {
+			slowest = rtt
+		}
+		total += rtt
+	}
+	avg := total / time.Duration(received)
+
+	metrics["rtt_min_ms"] = durationMillis(fastest)
+	metrics["rtt_avg_ms"] = durationMillis(avg)
+	metrics["rtt_max_ms"] = durationMillis(slowest)
+
+	return &CheckResult{
+		Status:       "up",
+		ResponseTime: int(avg.Milliseconds()),
+		Metrics:      metrics,
+	}
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yourusername/bjishk/internal/transport"
+)
+
+// websocketProber performs the upgrade handshake and, when configured, sends
+// a message and waits for a reply matching the expect pattern.
+type websocketProber struct{}
+
+func init() {
+	Register("ws", websocketProber{})
+	Register("wss", websocketProber{})
+}
+
+func (websocketProber) PerAddress() bool {
+	return true
+}
+
+func (websocketProber) Probe(ctx context.Context, target *Target) *CheckResult {
+	patient := &target.Patient
+
+	header := http.Header{}
+	header.Set("User-Agent", "Bjishk Health Monitor/1.0")
+	for name, value := range patient.Headers {
+		header.Set(name, value)
+	}
+	if patient.BasicAuth != nil {
+		credentials := patient.BasicAuth.Username + ":" + patient.BasicAuth.Password
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	} else if patient.BearerToken != "" {
+		header.Set("Authorization", "Bearer "+patient.BearerToken)
+	}
+
+	proxy, err := transport.Proxy(target.Network)
+	if err != nil {
+		return &CheckResult{Status: "unknown", Error: fmt.Sprintf("Invalid connection settings: %v", err)}
+	}
+	tlsConfig, err := transport.ClientTLS(patient.TLSConfig)
+	if err != nil {
+		return &CheckResult{Status: "unknown", Error: fmt.Sprintf("Invalid connection settings: %v", err)}
+	}
+
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: target.Timeout,
+		Proxy:            proxy,
+		NetDialContext:   transport.NewDialer(target.Network).DialContext,
+		TLSClientConfig:  tlsConfig,
+	}
+
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, target.Service.URL, header)
+	handshake := time.Since(start)
+	if err != nil {
+		msg := fmt.Sprintf("Handshake failed: %v", err)
+		if resp != nil {
+			msg = fmt.Sprintf("Handshake failed: HTTP %s", resp.Status)
+		}
+		return &CheckResult{Status: "down", Error: msg}
+	}
+	defer conn.Close()
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+
+	result := &CheckResult{
+		Status:       "up",
+		ResponseTime: int(handshake.Milliseconds()),
+		Metrics: map[string]float64{
+			"handshake_ms": float64(handshake.Milliseconds()),
+		},
+	}
+	if tlsConn, ok := conn.UnderlyingConn().(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		result.Certificate = certInfo(&state)
+	}
+
+	if patient.Send == "" && patient.Expect == "" {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		return result
+	}
+
+	deadline := start.Add(target.Timeout)
+	conn.SetWriteDeadline(deadline)
+	conn.SetReadDeadline(deadline)
+
+	sent := time.Now()
+	if patient.Send != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(patient.Send)); err != nil {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("Send failed: %v", err)
+			return result
+		}
+	}
+
+	var pattern *regexp.Regexp
+	if patient.Expect != "" {
+		pattern, err = regexp.Compile(patient.Expect)
+		if err != nil {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("Invalid expect pattern: %v", err)
+			return result
+		}
+	}
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("No matching reply: %v", err)
+			return result
+		}
+		if pattern == nil || pattern.Match(message) {
+			break
+		}
+	}
+
+	roundTrip := time.Since(sent)
+	result.ResponseTime = int((handshake + roundTrip).Milliseconds())
+	result.Metrics["round_trip_ms"] = float64(roundTrip.Milliseconds())
+
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	return result
+}
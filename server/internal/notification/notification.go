@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -14,7 +15,8 @@ type Service struct {
 	config   EmailConfig
 	dialer   *gomail.Dialer
 	ticker   *time.Ticker
-	quit     chan struct{}
+	ctx      context.Context // Cancelled by StopProcessing
+	cancel   context.CancelFunc
 	wg       sync.WaitGroup
 }
 
@@ -28,12 +30,14 @@ type EmailConfig struct {
 
 func New(db *database.DB, config EmailConfig) *Service {
 	dialer := gomail.NewDialer(config.SMTPServer, config.SMTPPort, config.SMTPUser, config.SMTPPassword)
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Service{
 		db:     db,
 		config: config,
 		dialer: dialer,
-		quit:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
@@ -46,18 +50,30 @@ func (s *Service) VerifyConnection() bool {
 	return true
 }
 
-func (s *Service) SendEmail(to, subject, body string) error {
+// SendEmail delivers a message, giving up when ctx is done. gomail has no
+// context support, so an abandoned send finishes in the background.
+func (s *Service) SendEmail(ctx context.Context, to, subject, body string) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", s.config.FromEmail)
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", body)
 
-	return s.dialer.DialAndSend(m)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.dialer.DialAndSend(m)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (s *Service) ProcessNotifications(adminEmail string) {
-	notifications, err := s.db.GetPendingNotifications()
+func (s *Service) ProcessNotifications(ctx context.Context, adminEmail string) {
+	notifications, err := s.db.GetPendingNotifications(ctx)
 	if err != nil {
 		fmt.Printf("❌ Failed to get pending notifications: %v\n", err)
 		return
@@ -73,16 +89,20 @@ func (s *Service) ProcessNotifications(adminEmail string) {
 		subject := "Bjishk Health Monitor Alert"
 		body := notif.Message
 
-		err := s.SendEmail(adminEmail, subject, body)
+		err := s.SendEmail(ctx, adminEmail, subject, body)
+		if ctx.Err() != nil {
+			// Shutting down; leave the rest pending for the next start
+			return
+		}
 		notifID := int(notif.ID)
 		if err != nil {
 			errMsg := err.Error()
-			if err := s.db.MarkNotificationSent(notifID, false, &errMsg); err != nil {
+			if err := s.db.MarkNotificationSent(ctx, notifID, false, &errMsg); err != nil {
 				fmt.Printf("   ❌ Failed to mark notification as failed: %v\n", err)
 			}
 			fmt.Printf("   ❌ Failed to send notification %d: %v\n", notifID, err)
 		} else {
-			if err := s.db.MarkNotificationSent(notifID, true, nil); err != nil {
+			if err := s.db.MarkNotificationSent(ctx, notifID, true, nil); err != nil {
 				fmt.Printf("   ⚠️  Failed to mark notification as sent: %v\n", err)
 			}
 			fmt.Printf("   ✅ Sent notification %d\n", notifID)
@@ -100,8 +120,8 @@ func (s *Service) StartProcessing(adminEmail string) {
 		for {
 			select {
 			case <-s.ticker.C:
-				s.ProcessNotifications(adminEmail)
-			case <-s.quit:
+				s.ProcessNotifications(s.ctx, adminEmail)
+			case <-s.ctx.Done():
 				return
 			}
 		}
@@ -112,7 +132,7 @@ func (s *Service) StopProcessing() {
 	if s.ticker != nil {
 		s.ticker.Stop()
 	}
-	close(s.quit)
+	s.cancel()
 	s.wg.Wait()
 }
 
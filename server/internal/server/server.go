@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/yourusername/bjishk/internal/database"
@@ -21,6 +22,8 @@ type Server struct {
 	port            int
 	refreshInterval int
 	httpServer      *http.Server
+	onPing          func(serviceID uint)
+	schedulerStats  func() models.SchedulerStats
 }
 
 func New(db *database.DB, fed *federation.Service, instanceName string, port int, refreshInterval int) *Server {
@@ -33,6 +36,18 @@ func New(db *database.DB, fed *federation.Service, instanceName string, port int
 	}
 }
 
+// SetPingHandler registers a callback run after a heartbeat ping has been
+// recorded, so the patient's status can be re-evaluated right away. It must
+// not block.
+func (s *Server) SetPingHandler(handler func(serviceID uint)) {
+	s.onPing = handler
+}
+
+// SetSchedulerStats registers the source of the /api/scheduler metrics.
+func (s *Server) SetSchedulerStats(stats func() models.SchedulerStats) {
+	s.schedulerStats = stats
+}
+
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
 
@@ -43,7 +58,7 @@ func (s *Server) Start() error {
 			return
 		}
 
-		health, err := s.federation.GetHealthStatus(s.instanceName)
+		health, err := s.federation.GetHealthStatus(r.Context(), s.instanceName)
 		if err != nil {
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
@@ -71,6 +86,22 @@ func (s *Server) Start() error {
 		json.NewEncoder(w).Encode(config)
 	})
 
+	// Check scheduler metrics (queue lag, busy workers)
+	mux.HandleFunc("/api/scheduler", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.schedulerStats == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(s.schedulerStats())
+	})
+
 	// UI endpoint for patient status
 	mux.HandleFunc("/api/patients", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -102,34 +133,62 @@ func (s *Server) Start() error {
 			endDate = &now
 		}
 
-		services, err := s.db.GetAllServices()
+		services, err := s.db.GetAllServices(r.Context())
 		if err != nil {
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 
-		type PatientLog struct {
+		type PatientTimings struct {
+			DNS      *int `json:"dns"`
+			Connect  *int `json:"connect"`
+			TLS      *int `json:"tls"`
+			TTFB     *int `json:"ttfb"`
+			Transfer *int `json:"transfer"`
+		}
+
+		type PatientAddress struct {
+			Address      string `json:"address"`
+			Family       string `json:"family"`
 			Status       string `json:"status"`
-			ResponseTime *int   `json:"response_time"`
-			CreatedAt    string `json:"created_at"`
+			ResponseTime int    `json:"response_time"`
+			Error        string `json:"error,omitempty"`
+		}
+
+		type PatientLog struct {
+			Status       string             `json:"status"`
+			ResponseTime *int               `json:"response_time"`
+			Timings      *PatientTimings    `json:"timings"`
+			Metrics      map[string]float64 `json:"metrics,omitempty"`
+			Addresses    []PatientAddress   `json:"addresses,omitempty"`
+			Message      *string            `json:"message"`
+			CreatedAt    string             `json:"created_at"`
+		}
+
+		type PatientCertificate struct {
+			ExpiresAt string   `json:"expires_at"`
+			DaysLeft  int      `json:"days_left"`
+			Issuer    *string  `json:"issuer"`
+			SANs      []string `json:"sans"`
 		}
 
 		type PatientResponse struct {
-			ID           uint         `json:"id"`
-			URL          string       `json:"url"`
-			Name         *string      `json:"name"`
-			Status       string       `json:"status"`
-			ResponseTime *int         `json:"response_time"`
-			LastCheck    *string      `json:"last_check"`
-			IsBjishk     bool         `json:"is_bjishk"`
-			Logs         []PatientLog `json:"logs"`
+			ID           uint                `json:"id"`
+			URL          string              `json:"url"`
+			Name         *string             `json:"name"`
+			Status       string              `json:"status"`
+			ResponseTime *int                `json:"response_time"`
+			LastCheck    *string             `json:"last_check"`
+			IsBjishk     bool                `json:"is_bjishk"`
+			Certificate  *PatientCertificate `json:"certificate"`
+			Logs         []PatientLog        `json:"logs"`
 		}
 
 		var response []PatientResponse
 
 		totalLogs := 0
 		for _, svc := range services {
-			logs, err := s.db.GetServiceLogsWithDateRange(int(svc.ID), startDate, endDate, 200)
+			logs, err := s.db.GetServiceLogsWithDateRange(r.Context(), int(svc.ID), startDate, endDate, 200)
 			if err != nil {
 				logs = []models.Log{} // Initialize empty slice on error
 			}
@@ -137,9 +196,31 @@ func (s *Server) Start() error {
 
 			var patientLogs []PatientLog
 			for _, log := range logs {
+				var timings *PatientTimings
+				if log.TTFB != nil {
+					timings = &PatientTimings{
+						DNS:      log.DNSTime,
+						Connect:  log.ConnectTime,
+						TLS:      log.TLSTime,
+						TTFB:     log.TTFB,
+						Transfer: log.TransferTime,
+					}
+				}
+				var metrics map[string]float64
+				if log.Metrics != nil {
+					json.Unmarshal([]byte(*log.Metrics), &metrics)
+				}
+				var addresses []PatientAddress
+				if log.Addresses != nil {
+					json.Unmarshal([]byte(*log.Addresses), &addresses)
+				}
 				patientLogs = append(patientLogs, PatientLog{
 					Status:       log.Status,
 					ResponseTime: log.ResponseTime,
+					Timings:      timings,
+					Metrics:      metrics,
+					Addresses:    addresses,
+					Message:      log.Message,
 					CreatedAt:    log.CreatedAt.Format(time.RFC3339),
 				})
 			}
@@ -161,6 +242,20 @@ func (s *Server) Start() error {
 				isBjishk = true
 			}
 
+			var certificate *PatientCertificate
+			if svc.CertExpiresAt != nil {
+				sans := []string{}
+				if svc.CertSANs != nil && *svc.CertSANs != "" {
+					sans = strings.Split(*svc.CertSANs, ",")
+				}
+				certificate = &PatientCertificate{
+					ExpiresAt: svc.CertExpiresAt.Format(time.RFC3339),
+					DaysLeft:  int(time.Until(*svc.CertExpiresAt).Hours() / 24),
+					Issuer:    svc.CertIssuer,
+					SANs:      sans,
+				}
+			}
+
 			response = append(response, PatientResponse{
 				ID:           svc.ID,
 				URL:          svc.URL,
@@ -169,16 +264,59 @@ func (s *Server) Start() error {
 				ResponseTime: svc.ResponseTime,
 				LastCheck:    lastCheck,
 				IsBjishk:     isBjishk,
+				Certificate:  certificate,
 				Logs:         patientLogs,
 			})
 		}
 
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		json.NewEncoder(w).Encode(response)
 	})
 
+	// Heartbeat pings: /api/ping/<token>, /api/ping/<token>/start, /api/ping/<token>/fail
+	mux.HandleFunc("/api/ping/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/ping/"), "/"), "/")
+		token := parts[0]
+		kind := "success"
+		if len(parts) == 2 && (parts[1] == "start" || parts[1] == "fail") {
+			kind = parts[1]
+		} else if len(parts) != 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		service, err := s.db.GetServiceByPingToken(r.Context(), token)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if service == nil || token == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := s.db.UpdateService(r.Context(), int(service.ID), map[string]interface{}{
+			"last_ping":        time.Now(),
+			"last_ping_status": kind,
+		}); err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if s.onPing != nil {
+			s.onPing(service.ID)
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("OK\n"))
+	})
+
 	// Serve static files from client/dist
 	distPath := filepath.Join(".", "client", "dist")
 	if _, err := os.Stat(distPath); err == nil {
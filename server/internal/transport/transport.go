@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/yourusername/bjishk/internal/config"
+)
+
+// Dialer makes TCP connections honoring the resolver and host overrides of
+// a network configuration.
+type Dialer struct {
+	net.Dialer
+	resolve map[string]string
+}
+
+// NewDialer returns a dialer for the given network settings.
+func NewDialer(network config.NetworkConfig) *Dialer {
+	d := &Dialer{
+		Dialer: net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+		resolve: network.Resolve,
+	}
+
+	if network.Resolver != "" {
+		server := network.Resolver
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolverDialer := &net.Dialer{Timeout: 5 * time.Second}
+		d.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return resolverDialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return d
+}
+
+// DialContext connects to addr, replacing the host when a "host:port" or
+// "host" override matches.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if address, ok := d.resolve[net.JoinHostPort(host, port)]; ok {
+			addr = net.JoinHostPort(address, port)
+		} else if address, ok := d.resolve[host]; ok {
+			addr = net.JoinHostPort(address, port)
+		}
+	}
+	return d.Dialer.DialContext(ctx, network, addr)
+}
+
+// DialTimeout is like net.DialTimeout, but also gives up when ctx is done.
+func (d *Dialer) DialTimeout(ctx context.Context, network, addr string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, addr)
+}
+
+// LookupIPAddr resolves host through the configured resolver, honoring a
+// "host" override.
+func (d *Dialer) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if address, ok := d.resolve[host]; ok {
+		if ip := net.ParseIP(address); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+		host = address
+	}
+
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return resolver.LookupIPAddr(ctx, host)
+}
+
+// Proxy returns the proxy function for the given network settings. Without
+// a configured proxy the environment (HTTP_PROXY, HTTPS_PROXY, NO_PROXY) is
+// used, like http.DefaultTransport does.
+func Proxy(network config.NetworkConfig) (func(*http.Request) (*url.URL, error), error) {
+	switch network.Proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "direct":
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(network.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// Proxied reports whether a request to rawURL goes through a proxy under
+// the given network settings. Only HTTP and WebSocket URLs are proxied.
+func Proxied(network config.NetworkConfig, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return false
+	}
+
+	proxy, err := Proxy(network)
+	if err != nil || proxy == nil {
+		return false
+	}
+	proxyURL, err := proxy(&http.Request{URL: u})
+	return err == nil && proxyURL != nil
+}
+
+// ClientTLS builds the TLS client configuration for the given settings.
+// Client certificates and CA bundles are read on every call so rotated
+// files are picked up.
+func ClientTLS(settings config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.Insecure,
+	}
+
+	if settings.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(settings.ClientCert, settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if settings.CABundle != "" {
+		pem, err := os.ReadFile(settings.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", settings.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// New returns an HTTP transport for the given network and TLS settings.
+// Callers own the transport and should call CloseIdleConnections when done
+// with it.
+func New(network config.NetworkConfig, settings config.TLSConfig) (*http.Transport, error) {
+	proxy, err := Proxy(network)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := ClientTLS(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	t.DialContext = NewDialer(network).DialContext
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
@@ -16,6 +16,15 @@ type Service struct {
 	Status              string         `gorm:"default:'unknown'"`
 	ConsecutiveFailures int            `gorm:"default:0"`
 	ResponseTime        *int           `gorm:"type:integer"`
+	CertExpiresAt       *time.Time     `gorm:"type:datetime"`
+	CertIssuer          *string        `gorm:"type:text"`
+	CertSANs            *string        `gorm:"column:cert_sans;type:text"`
+	CertNotifiedDays    *int           `gorm:"type:integer"`
+	PingToken           *string        `gorm:"uniqueIndex"`
+	LastPing            *time.Time     `gorm:"type:datetime"`
+	LastPingStatus      *string        `gorm:"type:text"`
+	ContentHash         *string        `gorm:"type:text"`
+	ContentSnapshot     *string        `gorm:"type:text"`
 	CreatedAt           time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt           time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt           gorm.DeletedAt `gorm:"index"`
@@ -51,6 +60,13 @@ type Log struct {
 	PeerID       *uint          `gorm:"type:integer"`
 	Status       string         `gorm:"not null"`
 	ResponseTime *int           `gorm:"type:integer"`
+	DNSTime      *int           `gorm:"type:integer"`
+	ConnectTime  *int           `gorm:"type:integer"`
+	TLSTime      *int           `gorm:"column:tls_time;type:integer"`
+	TTFB         *int           `gorm:"column:ttfb;type:integer"`
+	TransferTime *int           `gorm:"type:integer"`
+	Metrics      *string        `gorm:"type:text"` // JSON object of metric name to value
+	Addresses    *string        `gorm:"type:text"` // JSON array of per-address results for dual-stack checks
 	Message      *string        `gorm:"type:text"`
 	CreatedAt    time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt    time.Time      `gorm:"autoUpdateTime"`
@@ -58,8 +74,21 @@ type Log struct {
 }
 
 type ServiceStats struct {
-	Total   int
-	Up      int
-	Down    int
-	Unknown int
+	Total    int
+	Up       int
+	Degraded int
+	Down     int
+	Unknown  int
+}
+
+// SchedulerStats describes the check scheduler. Lag is the time a check
+// waited for a free worker after it was due.
+type SchedulerStats struct {
+	Workers    int     `json:"workers"`
+	Scheduled  int     `json:"scheduled"`
+	Running    int     `json:"running"`
+	Dispatched uint64  `json:"dispatched"`
+	LagLastMs  int64   `json:"lag_last_ms"`
+	LagAvgMs   float64 `json:"lag_avg_ms"`
+	LagMaxMs   int64   `json:"lag_max_ms"`
 }